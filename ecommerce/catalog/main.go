@@ -1,13 +1,10 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"html/template"
-	"io"
 	"log"
 	"net/http"
-	"os"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -22,10 +19,14 @@ type Products struct {
 	Products []Product
 }
 
-var productsUrl string
+var products productSource
 
 func init() {
-	productsUrl = os.Getenv("PRODUCT_URL")
+	src, err := newProductSource()
+	if err != nil {
+		log.Fatalf("Product service client failed: %v", err)
+	}
+	products = src
 }
 
 func main() {
@@ -39,42 +40,33 @@ func main() {
 }
 
 func listProducts(w http.ResponseWriter, request *http.Request) {
-	products := loadProducts()
+	all, err := products.List(request.Context())
+	if err != nil {
+		productError(w, err)
+		return
+	}
 	t := template.Must(template.ParseFiles("templates/catalog.html"))
-	t.Execute(w, products)
+	t.Execute(w, all)
 }
 
 func showProduct(w http.ResponseWriter, request *http.Request) {
 	productId := chi.URLParam(request, "id")
-	product := loadProductById(productId)
-	t := template.Must(template.ParseFiles("templates/view.html"))
-	t.Execute(w, product)
-}
-
-func loadProductById(productId string) Product {
-	response, err := http.Get(productsUrl + "/products/" + productId)
+	product, err := products.Get(request.Context(), productId)
 	if err != nil {
-		fmt.Printf("Http request failed %s\n", err)
+		productError(w, err)
+		return
 	}
-
-	data, _ := io.ReadAll(response.Body)
-
-	var product Product
-	json.Unmarshal(data, &product)
-
-	return product
+	t := template.Must(template.ParseFiles("templates/view.html"))
+	t.Execute(w, product)
 }
 
-func loadProducts() []Product {
-	response, err := http.Get(productsUrl + "/products")
-	if err != nil {
-		fmt.Printf("Http request failed %s\n", err)
+// productError answers with the HTTP status closest to a failed product
+// service call.
+func productError(w http.ResponseWriter, err error) {
+	log.Printf("Product service call failed: %v", err)
+	if errors.Is(err, errProductNotFound) {
+		http.Error(w, "product not found", http.StatusNotFound)
+		return
 	}
-
-	data, _ := io.ReadAll(response.Body)
-
-	var products Products
-	json.Unmarshal(data, &products)
-
-	return products.Products
+	http.Error(w, "product service unavailable", http.StatusBadGateway)
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	pb "grpc/product"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// productTimeout bounds each call to the product service.
+const productTimeout = 2 * time.Second
+
+var errProductNotFound = errors.New("product not found")
+
+// productSource loads products from the product service, over gRPC or the
+// older REST API, so the two can be compared side by side.
+type productSource interface {
+	Get(ctx context.Context, id string) (Product, error)
+	List(ctx context.Context) ([]Product, error)
+}
+
+// newProductSource picks the product API from PRODUCT_API: "grpc" (the
+// default) dials PRODUCT_GRPC_ADDR, "rest" calls PRODUCT_URL. Setting the
+// address of the API that is not in use is an error rather than silently
+// ignored.
+func newProductSource() (productSource, error) {
+	grpcAddr := os.Getenv("PRODUCT_GRPC_ADDR")
+	restURL := os.Getenv("PRODUCT_URL")
+	switch api := os.Getenv("PRODUCT_API"); api {
+	case "", "grpc":
+		if restURL != "" {
+			return nil, errors.New("PRODUCT_URL is set but PRODUCT_API is grpc; set PRODUCT_API=rest to use it")
+		}
+		if grpcAddr == "" {
+			grpcAddr = "localhost:4050"
+		}
+		conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		return grpcProducts{pb.NewProductServiceClient(conn)}, nil
+	case "rest":
+		if grpcAddr != "" {
+			return nil, errors.New("PRODUCT_GRPC_ADDR is set but PRODUCT_API is rest; set PRODUCT_API=grpc to use it")
+		}
+		if restURL == "" {
+			return nil, errors.New("PRODUCT_API is rest but PRODUCT_URL is not set")
+		}
+		return restProducts{baseURL: restURL, client: &http.Client{Timeout: productTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown PRODUCT_API %q, want grpc or rest", api)
+	}
+}
+
+type grpcProducts struct {
+	client pb.ProductServiceClient
+}
+
+func fromProto(p *pb.Product) Product {
+	return Product{Uuid: p.GetUuid(), Product: p.GetName(), Price: p.GetPrice()}
+}
+
+func grpcError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound, codes.InvalidArgument:
+		return fmt.Errorf("%w: %v", errProductNotFound, err)
+	}
+	return err
+}
+
+func (s grpcProducts) Get(ctx context.Context, id string) (Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, productTimeout)
+	defer cancel()
+	product, err := s.client.GetProduct(ctx, &pb.GetProductRequest{Uuid: id})
+	if err != nil {
+		return Product{}, grpcError(err)
+	}
+	return fromProto(product), nil
+}
+
+// List fetches the whole catalogue, one page at a time.
+func (s grpcProducts) List(ctx context.Context) ([]Product, error) {
+	var all []Product
+	req := &pb.ListProductsRequest{PageSize: 100}
+	for {
+		callCtx, cancel := context.WithTimeout(ctx, productTimeout)
+		resp, err := s.client.ListProducts(callCtx, req)
+		cancel()
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		for _, p := range resp.GetProducts() {
+			all = append(all, fromProto(p))
+		}
+		if resp.GetNextPageToken() == "" {
+			return all, nil
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+}
+
+type restProducts struct {
+	baseURL string
+	client  *http.Client
+}
+
+// getJSON decodes the body of a GET on path into v. The REST API answers an
+// unknown product with an empty body rather than a 404.
+func (s restProducts) getJSON(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errProductNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (s restProducts) Get(ctx context.Context, id string) (Product, error) {
+	var product Product
+	err := s.getJSON(ctx, "/products/"+url.PathEscape(id), &product)
+	if errors.Is(err, io.EOF) {
+		return Product{}, errProductNotFound
+	}
+	if err != nil {
+		return Product{}, err
+	}
+	return product, nil
+}
+
+func (s restProducts) List(ctx context.Context) ([]Product, error) {
+	var products Products
+	if err := s.getJSON(ctx, "/products", &products); err != nil {
+		return nil, err
+	}
+	return products.Products, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewProductSource(t *testing.T) {
+	tests := []struct {
+		name            string
+		api, grpc, rest string
+		want            any
+	}{
+		{"grpc by default", "", "", "", grpcProducts{}},
+		{"grpc", "grpc", "product:4050", "", grpcProducts{}},
+		{"rest", "rest", "", "http://product:4040", restProducts{}},
+		{"PRODUCT_URL ignored by grpc", "", "", "http://product:4040", nil},
+		{"PRODUCT_GRPC_ADDR ignored by rest", "rest", "product:4050", "http://product:4040", nil},
+		{"rest without PRODUCT_URL", "rest", "", "", nil},
+		{"unknown api", "soap", "", "", nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("PRODUCT_API", tc.api)
+			t.Setenv("PRODUCT_GRPC_ADDR", tc.grpc)
+			t.Setenv("PRODUCT_URL", tc.rest)
+			src, err := newProductSource()
+			switch tc.want.(type) {
+			case nil:
+				if err == nil {
+					t.Errorf("got a %T, want an error", src)
+				}
+			case grpcProducts:
+				if _, ok := src.(grpcProducts); !ok || err != nil {
+					t.Errorf("got %T, %v; want grpcProducts", src, err)
+				}
+			case restProducts:
+				if _, ok := src.(restProducts); !ok || err != nil {
+					t.Errorf("got %T, %v; want restProducts", src, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRestProducts(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/products", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"products":[{"uuid":"a","product":"Apple","price":"0.99"},{"uuid":"b","product":"Bread","price":"2.50"}]}`))
+	})
+	mux.HandleFunc("/products/a", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"uuid":"a","product":"Apple","price":"0.99"}`))
+	})
+	// Like the product service, answer an unknown product with an empty body.
+	mux.HandleFunc("/products/missing", func(w http.ResponseWriter, r *http.Request) {})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	src := restProducts{baseURL: srv.URL, client: srv.Client()}
+	ctx := context.Background()
+
+	all, err := src.List(ctx)
+	if err != nil || len(all) != 2 || all[1] != (Product{"b", "Bread", 2.5}) {
+		t.Errorf("List = %v, %v; want Apple and Bread", all, err)
+	}
+	if p, err := src.Get(ctx, "a"); err != nil || p != (Product{"a", "Apple", 0.99}) {
+		t.Errorf("Get(a) = %v, %v; want Apple", p, err)
+	}
+	if _, err := src.Get(ctx, "missing"); !errors.Is(err, errProductNotFound) {
+		t.Errorf("Get(missing) failed with %v, want errProductNotFound", err)
+	}
+	if _, err := src.Get(ctx, "gone"); !errors.Is(err, errProductNotFound) {
+		t.Errorf("Get on a 404 failed with %v, want errProductNotFound", err)
+	}
+}
@@ -3,12 +3,10 @@ package main
 import (
 	"checkout/queue"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"html/template"
-	"io"
 	"log"
 	"net/http"
-	"os"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -26,10 +24,14 @@ type Order struct {
 	ProductId string `json:"productId"`
 }
 
-var productsUrl string
+var products productSource
 
 func init() {
-	productsUrl = os.Getenv("PRODUCT_URL")
+	src, err := newProductSource()
+	if err != nil {
+		log.Fatalf("Product service client failed: %v", err)
+	}
+	products = src
 }
 
 func main() {
@@ -57,23 +59,18 @@ func finish(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Processed Checkout"))
 }
 
-func makeCheckout(w http.ResponseWriter, r *http.Request) { 
+func makeCheckout(w http.ResponseWriter, r *http.Request) {
 	productId := chi.URLParam(r, "id")
-	product := loadProductById(productId)
-	t := template.Must(template.ParseFiles("templates/checkout.html"))
-	t.Execute(w, product)
-}
-
-func loadProductById(productId string) Product {
-	response, err := http.Get(productsUrl + "/products/" + productId)
+	product, err := products.Get(r.Context(), productId)
 	if err != nil {
-		fmt.Printf("Http request failed %s\n", err)
+		log.Printf("Product service call failed: %v", err)
+		if errors.Is(err, errProductNotFound) {
+			http.Error(w, "product not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "product service unavailable", http.StatusBadGateway)
+		}
+		return
 	}
-
-	data, _ := io.ReadAll(response.Body)
-
-	var product Product
-	json.Unmarshal(data, &product)
-
-	return product
+	t := template.Must(template.ParseFiles("templates/checkout.html"))
+	t.Execute(w, product)
 }
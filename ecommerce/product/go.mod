@@ -1,5 +1,21 @@
 module product
 
-go 1.19
+go 1.22.2
 
-require github.com/go-chi/chi/v5 v5.0.8
+require (
+	github.com/go-chi/chi/v5 v5.0.8
+	google.golang.org/grpc v1.67.1
+	grpc v0.0.0-00010101000000-000000000000
+)
+
+require (
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20241015192408-796eee8c2d53 // indirect
+	google.golang.org/protobuf v1.35.2 // indirect
+)
+
+// The generated ProductService code lives with the other protos in the
+// grpc module at the root of this repository.
+replace grpc => ../../grpc
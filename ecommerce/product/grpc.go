@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	pb "grpc/product"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	// reloadInterval is how often products.json is checked for changes to
+	// send to WatchProducts callers.
+	reloadInterval = 2 * time.Second
+	// watchBuffer is how many events a watcher may fall behind by before
+	// it is disconnected.
+	watchBuffer = 64
+)
+
+// catalog is products.json kept in memory and reloaded when the file
+// changes, so watchers can be told what changed.
+type catalog struct {
+	path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	products []Product
+	watchers map[chan *pb.ProductEvent]struct{}
+}
+
+func newCatalog(path string) (*catalog, error) {
+	c := &catalog{path: path, watchers: make(map[chan *pb.ProductEvent]struct{})}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	go func() {
+		for range time.Tick(reloadInterval) {
+			if err := c.reload(); err != nil {
+				log.Printf("Reloading %s failed: %v", path, err)
+			}
+		}
+	}()
+	return c, nil
+}
+
+// reload reads the file if it changed since the last read and sends the
+// differences to every watcher.
+func (c *catalog) reload() error {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	unchanged := info.ModTime().Equal(c.modTime)
+	c.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	var products Products
+	if err := json.Unmarshal(data, &products); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	events := diffProducts(c.products, products.Products)
+	c.products = products.Products
+	c.modTime = info.ModTime()
+	for w := range c.watchers {
+	send:
+		for _, e := range events {
+			select {
+			case w <- e:
+			default:
+				log.Printf("Product watcher is not keeping up, disconnecting")
+				delete(c.watchers, w)
+				close(w)
+				break send
+			}
+		}
+	}
+	return nil
+}
+
+// diffProducts returns the events that turn before into after.
+func diffProducts(before, after []Product) []*pb.ProductEvent {
+	old := make(map[string]Product, len(before))
+	for _, p := range before {
+		old[p.Uuid] = p
+	}
+	var events []*pb.ProductEvent
+	for _, p := range after {
+		prev, ok := old[p.Uuid]
+		switch {
+		case !ok:
+			events = append(events, productEvent(pb.ProductEvent_ADDED, p))
+		case prev != p:
+			events = append(events, productEvent(pb.ProductEvent_UPDATED, p))
+		}
+		delete(old, p.Uuid)
+	}
+	for _, p := range before {
+		if _, ok := old[p.Uuid]; ok {
+			events = append(events, productEvent(pb.ProductEvent_REMOVED, p))
+		}
+	}
+	return events
+}
+
+func productEvent(t pb.ProductEvent_Type, p Product) *pb.ProductEvent {
+	return &pb.ProductEvent{Type: t, Product: toProto(p)}
+}
+
+func toProto(p Product) *pb.Product {
+	return &pb.Product{Uuid: p.Uuid, Name: p.Product, Price: p.Price}
+}
+
+// snapshot returns the current products.
+func (c *catalog) snapshot() []Product {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.products
+}
+
+// watch returns the current products and a channel that receives every
+// later change until unwatch is called or the watcher falls behind, in
+// which case the channel is closed.
+func (c *catalog) watch() ([]Product, chan *pb.ProductEvent) {
+	w := make(chan *pb.ProductEvent, watchBuffer)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.watchers[w] = struct{}{}
+	return c.products, w
+}
+
+func (c *catalog) unwatch(w chan *pb.ProductEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.watchers[w]; ok {
+		delete(c.watchers, w)
+		close(w)
+	}
+}
+
+type productServer struct {
+	pb.UnimplementedProductServiceServer
+
+	catalog *catalog
+}
+
+func (s *productServer) GetProduct(ctx context.Context, in *pb.GetProductRequest) (*pb.Product, error) {
+	if in.GetUuid() == "" {
+		return nil, status.Error(codes.InvalidArgument, "uuid is required")
+	}
+	for _, p := range s.catalog.snapshot() {
+		if p.Uuid == in.GetUuid() {
+			return toProto(p), nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "product %s not found", in.GetUuid())
+}
+
+// ListProducts pages by position in the catalogue, so a page token is just
+// the offset of the next product.
+func (s *productServer) ListProducts(ctx context.Context, in *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	if in.GetPageSize() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must not be negative, got %d", in.GetPageSize())
+	}
+	offset := 0
+	if in.GetPageToken() != "" {
+		b, err := base64.RawURLEncoding.DecodeString(in.GetPageToken())
+		if err == nil {
+			offset, err = strconv.Atoi(string(b))
+		}
+		if err != nil || offset < 0 {
+			return nil, status.Error(codes.InvalidArgument, "page_token is not a valid page token")
+		}
+	}
+	size := int(in.GetPageSize())
+	if size == 0 {
+		size = defaultPageSize
+	}
+	size = min(size, maxPageSize)
+
+	products := s.catalog.snapshot()
+	resp := &pb.ListProductsResponse{}
+	end := min(offset+size, len(products))
+	for _, p := range products[min(offset, end):end] {
+		resp.Products = append(resp.Products, toProto(p))
+	}
+	if end < len(products) {
+		resp.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end)))
+	}
+	return resp, nil
+}
+
+func (s *productServer) WatchProducts(in *pb.WatchProductsRequest, stream pb.ProductService_WatchProductsServer) error {
+	products, events := s.catalog.watch()
+	defer s.catalog.unwatch(events)
+	for _, p := range products {
+		if err := stream.Send(productEvent(pb.ProductEvent_ADDED, p)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "too many undelivered product events")
+			}
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+func serveGRPC(addr string) {
+	c, err := newCatalog("products.json")
+	if err != nil {
+		log.Fatalf("Loading products failed: %v", err)
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Listen failed: %v", err)
+	}
+	s := grpc.NewServer()
+	pb.RegisterProductServiceServer(s, &productServer{catalog: c})
+	reflection.Register(s)
+
+	log.Println("Start gRPC server " + addr)
+	if err := s.Serve(lis); err != nil {
+		log.Fatalf("gRPC server failed: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "grpc/product"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func makeProducts(n int) []Product {
+	products := make([]Product, n)
+	for i := range products {
+		products[i] = Product{Uuid: fmt.Sprintf("p%03d", i), Product: fmt.Sprintf("Product %d", i), Price: 1.5}
+	}
+	return products
+}
+
+// testCatalog is a catalog of products.json in a temp dir. Unlike
+// newCatalog it does not poll the file; the test calls write to change it.
+type testCatalog struct {
+	*catalog
+	t     *testing.T
+	mtime time.Time
+}
+
+func newTestCatalog(t *testing.T, products []Product) *testCatalog {
+	t.Helper()
+	tc := &testCatalog{
+		catalog: &catalog{
+			path:     filepath.Join(t.TempDir(), "products.json"),
+			watchers: make(map[chan *pb.ProductEvent]struct{}),
+		},
+		t:     t,
+		mtime: time.Unix(1_000_000, 0),
+	}
+	tc.write(products)
+	return tc
+}
+
+// write replaces the file with products and reloads it. Each write gets a
+// later modification time, so reload never mistakes it for the last one.
+func (tc *testCatalog) write(products []Product) {
+	tc.t.Helper()
+	data, err := json.Marshal(Products{Products: products})
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	if err := os.WriteFile(tc.path, data, 0o644); err != nil {
+		tc.t.Fatal(err)
+	}
+	tc.mtime = tc.mtime.Add(time.Second)
+	if err := os.Chtimes(tc.path, tc.mtime, tc.mtime); err != nil {
+		tc.t.Fatal(err)
+	}
+	if err := tc.reload(); err != nil {
+		tc.t.Fatal(err)
+	}
+}
+
+func (tc *testCatalog) watcherCount() int {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return len(tc.watchers)
+}
+
+func TestListProductsPaging(t *testing.T) {
+	s := &productServer{catalog: newTestCatalog(t, makeProducts(5)).catalog}
+	ctx := context.Background()
+
+	var got []string
+	var pages []int
+	req := &pb.ListProductsRequest{PageSize: 2}
+	for {
+		resp, err := s.ListProducts(ctx, req)
+		if err != nil {
+			t.Fatalf("ListProducts(%v) failed: %v", req, err)
+		}
+		pages = append(pages, len(resp.GetProducts()))
+		for _, p := range resp.GetProducts() {
+			got = append(got, p.GetUuid())
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		if len(pages) > 5 {
+			t.Fatalf("still paging after %v", pages)
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+	if fmt.Sprint(pages) != "[2 2 1]" {
+		t.Errorf("page sizes are %v, want [2 2 1]", pages)
+	}
+	if fmt.Sprint(got) != "[p000 p001 p002 p003 p004]" {
+		t.Errorf("paged through %v, want every product once, in order", got)
+	}
+}
+
+func TestListProductsPageSize(t *testing.T) {
+	s := &productServer{catalog: newTestCatalog(t, makeProducts(150)).catalog}
+	tests := []struct {
+		size int32
+		want int
+	}{
+		{0, defaultPageSize},
+		{7, 7},
+		{1000, maxPageSize},
+	}
+	for _, tc := range tests {
+		resp, err := s.ListProducts(context.Background(), &pb.ListProductsRequest{PageSize: tc.size})
+		if err != nil {
+			t.Fatalf("ListProducts(page_size %d) failed: %v", tc.size, err)
+		}
+		if got := len(resp.GetProducts()); got != tc.want {
+			t.Errorf("page_size %d returned %d products, want %d", tc.size, got, tc.want)
+		}
+	}
+}
+
+func TestListProductsRejectsBadRequests(t *testing.T) {
+	s := &productServer{catalog: newTestCatalog(t, makeProducts(5)).catalog}
+	for _, req := range []*pb.ListProductsRequest{
+		{PageSize: -1},
+		{PageToken: "not a token!"},
+		{PageToken: "LTE"}, // -1
+	} {
+		_, err := s.ListProducts(context.Background(), req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListProducts(%v) ended with %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestReloadDisconnectsSlowWatchers(t *testing.T) {
+	c := newTestCatalog(t, nil)
+	_, slow := c.watch()
+	_, fast := c.watch()
+
+	// Each write adds more than half a buffer of products. The fast
+	// watcher keeps up; the slow one never reads.
+	half := watchBuffer/2 + 1
+	received := 0
+	for i := 1; i <= 2; i++ {
+		c.write(makeProducts(i * half))
+		for range half {
+			if _, ok := <-fast; !ok {
+				t.Fatal("fast watcher was disconnected")
+			}
+			received++
+		}
+	}
+
+	buffered := 0
+	for range slow {
+		buffered++
+	}
+	if buffered != watchBuffer {
+		t.Errorf("slow watcher got %d events before being closed, want a full buffer of %d", buffered, watchBuffer)
+	}
+	if n := c.watcherCount(); n != 1 {
+		t.Errorf("%d watchers left, want only the fast one", n)
+	}
+
+	c.write(makeProducts(2*half + 1))
+	if e := <-fast; e.GetType() != pb.ProductEvent_ADDED || e.GetProduct().GetUuid() != fmt.Sprintf("p%03d", 2*half) {
+		t.Errorf("fast watcher got %v after the slow one left, want the added product", e)
+	}
+	c.unwatch(fast)
+}
+
+func TestWatchProductsUnwatchesOnDisconnect(t *testing.T) {
+	c := newTestCatalog(t, makeProducts(3))
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterProductServiceServer(s, &productServer{catalog: c.catalog})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := pb.NewProductServiceClient(conn).WatchProducts(ctx, &pb.WatchProductsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("Recv of the current products failed: %v", err)
+		}
+	}
+	c.write(makeProducts(4))
+	if e, err := stream.Recv(); err != nil || e.GetProduct().GetUuid() != "p003" {
+		t.Fatalf("Recv after a change = %v, %v; want p003 added", e, err)
+	}
+	if n := c.watcherCount(); n != 1 {
+		t.Fatalf("%d watchers while the stream is open, want 1", n)
+	}
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.watcherCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("watcher is still registered after the client went away")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// Later changes have nobody to go to.
+	c.write(makeProducts(5))
+}
@@ -27,6 +27,8 @@ func main() {
 	r.Get("/products", getProducts)
 	r.Get("/products/{id}", getProductsById)
 
+	go serveGRPC(":4050")
+
 	log.Println("Start server :4040")
 	http.ListenAndServe(":4040", r)
 }
@@ -56,13 +58,13 @@ func getProductsById(w http.ResponseWriter, request *http.Request) {
 func loadData() []byte {
 	file, err := os.Open("products.json")
 	if err != nil {
-		fmt.Sprintln(err)
+		fmt.Println(err)
 	}
 	defer file.Close()
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		fmt.Sprintln(err)
+		fmt.Println(err)
 	}
 
 	return data
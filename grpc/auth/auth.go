@@ -0,0 +1,129 @@
+// Package auth authenticates gRPC callers with bearer JWTs. The server side
+// is a pair of interceptors that verify the token, check the scope each
+// method requires and put the claims on the context; the client side is a
+// grpc.PerRPCCredentials that attaches the token to every call.
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"grpc/interceptor"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Claims are the token claims handlers can read with ClaimsFromContext.
+// Scope is a space-separated list, as in OAuth 2.0.
+type Claims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+// HasScope reports whether the token grants scope.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(strings.Fields(c.Scope), scope)
+}
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims of the authenticated caller.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(*Claims)
+	return c, ok
+}
+
+// Policy decides what each method requires. Methods whose full name starts
+// with one of Public skip authentication; Scopes maps full method names to
+// the scope a token must carry, and methods missing from it only need a
+// valid token.
+type Policy struct {
+	Public []string
+	Scopes map[string]string
+}
+
+// Authenticator verifies bearer tokens against a key and a Policy.
+type Authenticator struct {
+	key    Key
+	policy Policy
+	parser *jwt.Parser
+}
+
+// NewAuthenticator returns an Authenticator that accepts tokens signed with
+// key. Tokens must carry an expiry; issuer and audience are checked when
+// non-empty.
+func NewAuthenticator(key Key, policy Policy, issuer, audience string) *Authenticator {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{key.Method.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	return &Authenticator{key: key, policy: policy, parser: jwt.NewParser(opts...)}
+}
+
+// Unary returns the server interceptor for unary RPCs.
+func (a *Authenticator) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the server interceptor for streaming RPCs.
+func (a *Authenticator) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, interceptor.WrapServerStream(ss, ctx))
+	}
+}
+
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	for _, p := range a.policy.Public {
+		if strings.HasPrefix(method, p) {
+			return ctx, nil
+		}
+	}
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return a.key.Value, nil
+	}); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	if scope, ok := a.policy.Scopes[method]; ok && !claims.HasScope(scope) {
+		return nil, status.Errorf(codes.PermissionDenied, "token lacks scope %q required by %s", scope, method)
+	}
+	return context.WithValue(ctx, claimsKey{}, claims), nil
+}
+
+func bearerToken(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	v := md.Get("authorization")
+	if len(v) == 0 {
+		return "", status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+	token, ok := strings.CutPrefix(v[0], "Bearer ")
+	if !ok || token == "" {
+		return "", status.Error(codes.Unauthenticated, "authorization metadata is not a bearer token")
+	}
+	return token, nil
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a JWT signing or verification key together with the algorithm it
+// is used with.
+type Key struct {
+	Method jwt.SigningMethod
+	Value  any
+}
+
+// LoadVerificationKey reads a key used to check token signatures. A PEM
+// public key or certificate selects RS256; anything else is taken as an
+// HS256 shared secret.
+func LoadVerificationKey(path string) (Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("read verification key: %w", err)
+	}
+	if block, _ := pem.Decode(b); block != nil {
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(b)
+		if err != nil {
+			return Key{}, fmt.Errorf("parse RSA public key: %w", err)
+		}
+		return Key{Method: jwt.SigningMethodRS256, Value: pub}, nil
+	}
+	return hmacKey(b)
+}
+
+// LoadSigningKey reads a key used to sign tokens: a PEM RSA private key for
+// RS256, or an HS256 shared secret.
+func LoadSigningKey(path string) (Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("read signing key: %w", err)
+	}
+	if block, _ := pem.Decode(b); block != nil {
+		priv, err := jwt.ParseRSAPrivateKeyFromPEM(b)
+		if err != nil {
+			return Key{}, fmt.Errorf("parse RSA private key: %w", err)
+		}
+		return Key{Method: jwt.SigningMethodRS256, Value: priv}, nil
+	}
+	return hmacKey(b)
+}
+
+func hmacKey(b []byte) (Key, error) {
+	secret := bytes.TrimSpace(b)
+	if len(secret) < 32 {
+		return Key{}, fmt.Errorf("HMAC secret must be at least 32 bytes, got %d", len(secret))
+	}
+	return Key{Method: jwt.SigningMethodHS256, Value: secret}, nil
+}
@@ -0,0 +1,167 @@
+// Package gateway is a hand-written HTTP/JSON front end for the Greeter
+// service, for callers that can't speak gRPC. It forwards each request to a
+// GreeterClient and translates the gRPC status into an HTTP response.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	pb "grpc/helloworld"
+
+	// Registers google.rpc.BadRequest and friends so status details
+	// can be rendered as JSON.
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// metadataHeaderPrefix marks HTTP headers that are passed through to the
+// gRPC call as metadata with the prefix removed.
+const metadataHeaderPrefix = "Grpc-Metadata-"
+
+// maxRequestBody bounds the JSON body read from callers. A HelloRequest is
+// a short name and a language tag, far below this.
+const maxRequestBody = 64 << 10
+
+// forwardedHeaders are passed through as metadata under their own name.
+var forwardedHeaders = []string{"Authorization", "X-Request-Id", "Accept-Language"}
+
+var (
+	unmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+	marshaler   = protojson.MarshalOptions{EmitUnpopulated: true}
+)
+
+// New returns a handler serving
+//
+//	POST /v1/greet       -> Greeter.SayHello
+//	POST /v1/greet/again -> Greeter.SayHelloAgain
+//
+// Bodies are HelloRequest/HelloReply in protobuf JSON form.
+func New(c pb.GreeterClient) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /v1/greet", unary(c.SayHello))
+	mux.Handle("POST /v1/greet/again", unary(c.SayHelloAgain))
+	return mux
+}
+
+type greetFunc func(ctx context.Context, in *pb.HelloRequest, opts ...grpc.CallOption) (*pb.HelloReply, error)
+
+func unary(call greetFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			st := status.Newf(codes.InvalidArgument, "request body is larger than %d bytes", tooLarge.Limit)
+			writeMessage(w, http.StatusRequestEntityTooLarge, st.Proto())
+			return
+		}
+		if err != nil {
+			writeError(w, status.Errorf(codes.InvalidArgument, "read body: %v", err))
+			return
+		}
+		in := &pb.HelloRequest{}
+		if len(body) > 0 {
+			if err := unmarshaler.Unmarshal(body, in); err != nil {
+				writeError(w, status.Errorf(codes.InvalidArgument, "decode body: %v", err))
+				return
+			}
+		}
+
+		var header, trailer metadata.MD
+		ctx := metadata.NewOutgoingContext(r.Context(), outgoingMetadata(r))
+		out, err := call(ctx, in, grpc.Header(&header), grpc.Trailer(&trailer))
+		copyRequestID(w, header, trailer)
+		if err != nil {
+			for _, v := range trailer.Get("retry-after") {
+				w.Header().Add("Retry-After", v)
+			}
+			writeError(w, err)
+			return
+		}
+		writeMessage(w, http.StatusOK, out)
+	})
+}
+
+// copyRequestID sets X-Request-Id from the call's response headers, or from
+// its trailers when the call failed before sending any headers.
+func copyRequestID(w http.ResponseWriter, header, trailer metadata.MD) {
+	ids := header.Get("x-request-id")
+	if len(ids) == 0 {
+		ids = trailer.Get("x-request-id")
+	}
+	for _, v := range ids {
+		w.Header().Add("X-Request-Id", v)
+	}
+}
+
+func outgoingMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	for _, h := range forwardedHeaders {
+		if v := r.Header.Values(h); len(v) > 0 {
+			md.Append(strings.ToLower(h), v...)
+		}
+	}
+	for k, v := range r.Header {
+		if key, ok := strings.CutPrefix(k, metadataHeaderPrefix); ok {
+			md.Append(strings.ToLower(key), v...)
+		}
+	}
+	return md
+}
+
+// writeError renders err as a google.rpc.Status JSON body with the HTTP
+// status that corresponds to its code.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writeMessage(w, HTTPStatusFromCode(st.Code()), st.Proto())
+}
+
+func writeMessage(w http.ResponseWriter, code int, m proto.Message) {
+	b, err := marshaler.Marshal(m)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
+
+// HTTPStatusFromCode maps a gRPC status code to the HTTP status used by
+// google.api.http transcoding.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default: // Unknown, Internal, DataLoss
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "grpc/helloworld"
+
+	"google.golang.org/grpc/status"
+)
+
+// benchResult is what a load test reports, on the terminal or as JSON.
+type benchResult struct {
+	RPC         string         `json:"rpc"`
+	Concurrency int            `json:"concurrency"`
+	PayloadSize int            `json:"payload_size"`
+	Requests    int            `json:"requests"`
+	Elapsed     float64        `json:"elapsed_seconds"`
+	Throughput  float64        `json:"requests_per_second"`
+	Latency     latencySummary `json:"latency_ms"`
+	Histogram   []histogramBin `json:"histogram"`
+	Errors      map[string]int `json:"errors"`
+}
+
+type latencySummary struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+type histogramBin struct {
+	UpToMs float64 `json:"up_to_ms"`
+	Count  int     `json:"count"`
+}
+
+// benchCall returns the function a load test worker calls repeatedly.
+func benchCall(c pb.GreeterClient, rpc string, payload string) (func(context.Context) error, error) {
+	req := &pb.HelloRequest{Name: payload}
+	switch rpc {
+	case "SayHello":
+		return func(ctx context.Context) error {
+			_, err := c.SayHello(ctx, req)
+			return err
+		}, nil
+	case "SayHelloAgain":
+		return func(ctx context.Context) error {
+			_, err := c.SayHelloAgain(ctx, req)
+			return err
+		}, nil
+	case "SayHelloStream":
+		sreq := &pb.HelloStreamRequest{Name: payload, Count: int32(*streamCount), IntervalMs: int32(streamInterval.Milliseconds())}
+		return func(ctx context.Context) error {
+			stream, err := c.SayHelloStream(ctx, sreq)
+			if err != nil {
+				return err
+			}
+			for {
+				if _, err := stream.Recv(); err == io.EOF {
+					return nil
+				} else if err != nil {
+					return err
+				}
+			}
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown rpc %q; want SayHello, SayHelloAgain or SayHelloStream", rpc)
+	}
+}
+
+// runBench calls the -bench_rpc method from -bench_concurrency workers
+// until -bench_requests calls have been made or -bench_duration has passed,
+// whichever comes first, and reports latency and errors.
+func runBench(ctx context.Context, c pb.GreeterClient) error {
+	payload := strings.Repeat("a", *payloadSize)
+	call, err := benchCall(c, *benchRPC, payload)
+	if err != nil {
+		return err
+	}
+	if *benchRequests <= 0 && *benchDuration <= 0 {
+		return fmt.Errorf("-bench_requests or -bench_duration must be set")
+	}
+	if *benchDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *benchDuration)
+		defer cancel()
+	}
+
+	var (
+		issued    atomic.Int64
+		mu        sync.Mutex
+		latencies []time.Duration
+		errs      = make(map[string]int)
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for range *concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local []time.Duration
+			localErrs := make(map[string]int)
+			for ctx.Err() == nil {
+				if *benchRequests > 0 && issued.Add(1) > int64(*benchRequests) {
+					break
+				}
+				callCtx, cancel := context.WithTimeout(ctx, *callTimeout)
+				t := time.Now()
+				err := call(callCtx)
+				d := time.Since(t)
+				cancel()
+				// Calls cut short by the end of -bench_duration aren't the
+				// server's fault; leave them out.
+				if err != nil && ctx.Err() != nil {
+					break
+				}
+				local = append(local, d)
+				if err != nil {
+					localErrs[status.Code(err).String()]++
+				}
+			}
+			mu.Lock()
+			latencies = append(latencies, local...)
+			for k, v := range localErrs {
+				errs[k] += v
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	res := summarize(latencies, elapsed, errs)
+	if *benchJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	printBench(res)
+	return nil
+}
+
+func summarize(latencies []time.Duration, elapsed time.Duration, errs map[string]int) benchResult {
+	res := benchResult{
+		RPC:         *benchRPC,
+		Concurrency: *concurrency,
+		PayloadSize: *payloadSize,
+		Requests:    len(latencies),
+		Elapsed:     elapsed.Seconds(),
+		Throughput:  float64(len(latencies)) / elapsed.Seconds(),
+		Errors:      errs,
+	}
+	if len(latencies) == 0 {
+		return res
+	}
+	slices.Sort(latencies)
+	var total time.Duration
+	for _, d := range latencies {
+		total += d
+	}
+	res.Latency = latencySummary{
+		Min:  ms(latencies[0]),
+		Mean: ms(total / time.Duration(len(latencies))),
+		P50:  ms(percentile(latencies, 50)),
+		P90:  ms(percentile(latencies, 90)),
+		P99:  ms(percentile(latencies, 99)),
+		Max:  ms(latencies[len(latencies)-1]),
+	}
+	res.Histogram = histogram(latencies)
+	return res
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+// histogram buckets sorted latencies into bins that double in width from
+// 0.1ms, from the bin holding the fastest call to the one holding the
+// slowest.
+func histogram(sorted []time.Duration) []histogramBin {
+	var bins []histogramBin
+	i := 0
+	for upTo := 0.1; i < len(sorted); upTo *= 2 {
+		n := 0
+		for i < len(sorted) && ms(sorted[i]) <= upTo {
+			n++
+			i++
+		}
+		if n > 0 || len(bins) > 0 {
+			bins = append(bins, histogramBin{UpToMs: upTo, Count: n})
+		}
+	}
+	return bins
+}
+
+func printBench(res benchResult) {
+	log.Printf("%s x%d, %d-byte names: %d requests in %.2fs, %.1f req/s",
+		res.RPC, res.Concurrency, res.PayloadSize, res.Requests, res.Elapsed, res.Throughput)
+	if res.Requests == 0 {
+		return
+	}
+	l := res.Latency
+	log.Printf("latency ms: min %.2f  mean %.2f  p50 %.2f  p90 %.2f  p99 %.2f  max %.2f", l.Min, l.Mean, l.P50, l.P90, l.P99, l.Max)
+	peak := 0
+	for _, b := range res.Histogram {
+		peak = max(peak, b.Count)
+	}
+	for _, b := range res.Histogram {
+		bar := strings.Repeat("#", int(math.Ceil(40*float64(b.Count)/float64(peak))))
+		log.Printf("  <= %9.1fms %8d %s", b.UpToMs, b.Count, bar)
+	}
+	codes := make([]string, 0, len(res.Errors))
+	for code := range res.Errors {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	for _, code := range codes {
+		log.Printf("errors: %-18s %d", code, res.Errors[code])
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
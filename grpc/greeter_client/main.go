@@ -1,48 +1,361 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	_ "embed"
+	"errors"
 	"flag"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
 	"time"
 
+	"grpc/auth"
 	pb "grpc/helloworld"
+	"grpc/interceptor"
+	"grpc/staticresolver"
+	"grpc/telemetry"
+	"grpc/tlsutil"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	defaultName = "world"
 )
 
+// defaultServiceConfig balances calls round robin across every resolved
+// address, retries UNAVAILABLE with backoff and bounds each unary call to
+// two seconds, attempts included.
+//
+//go:embed service_config.json
+var defaultServiceConfig string
+
 var (
-	addr = flag.String("addr", "localhost:50051", "the address to connect to")
-	name = flag.String("name", defaultName, "Name to greet")
+	addr   = flag.String("addr", "localhost:50051", "the address to connect to; a comma-separated list, or a static:/// or file:/// target, to balance across several servers")
+	name   = flag.String("name", defaultName, "Name to greet")
+	repeat = flag.Int("repeat", 1, "Number of times to send the unary greetings")
+	lang   = flag.String("language", "", "Language to be greeted in, such as pt-BR; the server falls back to its default when it has no translation")
+
+	traceExporter = flag.String("trace_exporter", "none", "Where to send OpenTelemetry spans: none or stdout")
+
+	resolvePoll = flag.Duration("resolve_poll", 5*time.Second, "How often a file:/// target is re-read")
+
+	tlsCA         = flag.String("tls_ca", "", "CA bundle used to verify the server (PEM); system roots when empty")
+	tlsCert       = flag.String("tls_cert", "", "Client certificate file for mutual TLS (PEM)")
+	tlsKey        = flag.String("tls_key", "", "Client private key file for mutual TLS (PEM)")
+	tlsServerName = flag.String("tls_server_name", "", "Override the server name used to verify the server certificate")
+	plaintext     = flag.Bool("insecure", false, "Connect without TLS; only for local development")
+	jwtTokenFile  = flag.String("jwt_token_file", "", "File holding a bearer JWT to send with every call")
+
+	serviceConfig = flag.String("service_config", "", "Service config JSON, or a path to a file holding it; the embedded service_config.json when empty")
+	callTimeout   = flag.Duration("timeout", 5*time.Second, "Upper bound on each unary call on top of the service config timeouts")
+
+	compression    = flag.String("compression", "none", "Compress requests with none, gzip or zstd")
+	maxRecvMsgSize = flag.Int("max_recv_msg_size", 0, "Largest reply message in bytes, after decompression; 0 keeps the gRPC default of 4 MiB")
+	maxSendMsgSize = flag.Int("max_send_msg_size", 0, "Largest request message in bytes; 0 keeps the gRPC default, which is unlimited")
+
+	keepaliveTime                = flag.Duration("keepalive_time", 0, "Ping the server after the connection has been idle this long; 0 disables client keepalive")
+	keepaliveTimeout             = flag.Duration("keepalive_timeout", 20*time.Second, "Close the connection if a keepalive ping is not answered within this")
+	keepalivePermitWithoutStream = flag.Bool("keepalive_permit_without_stream", false, "Send keepalive pings even when no calls are active")
+
+	breakerFailureRate = flag.Float64("breaker_failure_rate", 0.5, "Open the circuit breaker once this fraction of unary calls in -breaker_window fail; 0 disables it")
+	breakerMinRequests = flag.Int("breaker_min_requests", 5, "Calls -breaker_window must hold before the breaker can open")
+	breakerWindow      = flag.Duration("breaker_window", 10*time.Second, "How long failures are counted before the breaker's counts start over")
+	breakerCoolDown    = flag.Duration("breaker_cooldown", 5*time.Second, "How long the breaker fails calls fast before letting a probe through")
+	breakerProbes      = flag.Int("breaker_probes", 1, "Probe calls that must succeed before the breaker closes again")
+
+	streamCount    = flag.Int("stream_count", 0, "Number of greetings to request from SayHelloStream (0 uses the server default)")
+	streamInterval = flag.Duration("stream_interval", 0, "Pause between streamed greetings (0 uses the server default)")
+	cancelAfter    = flag.Int("cancel_after", 0, "Cancel the stream after receiving this many greetings (0 reads to the end)")
+
+	chat = flag.Bool("chat", false, "Join the chat room, sending lines read from stdin")
+
+	list         = flag.Bool("list", false, "List the greetings the server has sent instead of greeting")
+	listName     = flag.String("list_name", "", "Only list greetings for this name")
+	listPageSize = flag.Int("list_page_size", 0, "Greetings to fetch per ListGreetings call (0 uses the server default)")
+
+	bench         = flag.Bool("bench", false, "Load test the server instead of greeting once; the circuit breaker is off in this mode")
+	benchRPC      = flag.String("bench_rpc", "SayHello", "Method to load test: SayHello, SayHelloAgain or SayHelloStream")
+	benchRequests = flag.Int("bench_requests", 1000, "Total calls to make in -bench mode; 0 runs for -bench_duration")
+	benchDuration = flag.Duration("bench_duration", 0, "Stop -bench mode after this long, even if -bench_requests have not all been made")
+	concurrency   = flag.Int("bench_concurrency", 10, "Concurrent callers in -bench mode")
+	payloadSize   = flag.Int("bench_payload_size", 8, "Length of the name sent in -bench mode; the server rejects names over 64 characters")
+	benchJSON     = flag.Bool("bench_json", false, "Print -bench results as JSON")
 )
 
 func main() {
 	flag.Parse()
 	// Set up a connection to the server.
-	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	creds, err := clientCredentials()
+	if err != nil {
+		log.Fatalf("failed to load credentials: %v", err)
+	}
+	sc, err := loadServiceConfig()
+	if err != nil {
+		log.Fatalf("failed to load service config: %v", err)
+	}
+	// grpc-go applies retryPolicy and timeouts itself; hedgingPolicy is left
+	// to the interceptor.
+	hedging, err := interceptor.ParseHedgingPolicies(sc)
+	if err != nil {
+		log.Fatalf("failed to load service config: %v", err)
+	}
+	target := *addr
+	if strings.Contains(target, ",") {
+		target = "static:///" + target
+	}
+	exporter, err := telemetry.NewExporter(*traceExporter)
+	if err != nil {
+		log.Fatalf("failed to set up tracing: %v", err)
+	}
+	tp := telemetry.NewTracerProvider("greeter_client", exporter)
+	defer telemetry.Shutdown(context.Background(), tp)
+	// Every call below is a child of this span, so one trace covers the run.
+	ctx, span := tp.Tracer("greeter_client").Start(context.Background(), "greeter_client")
+	defer span.End()
+
+	// The breaker goes outside hedging and retries, so it sees one outcome
+	// per call and an open breaker stops the attempts from being made at all.
+	// A load test wants to see the server's own failures and latency, not
+	// the fast local rejections of an open breaker, so it runs without one.
+	var unary []grpc.UnaryClientInterceptor
+	if *breakerFailureRate > 0 && !*bench {
+		breaker := interceptor.NewCircuitBreaker(interceptor.BreakerConfig{
+			FailureRate: *breakerFailureRate,
+			MinRequests: *breakerMinRequests,
+			Window:      *breakerWindow,
+			CoolDown:    *breakerCoolDown,
+			Probes:      *breakerProbes,
+			OnStateChange: func(from, to interceptor.BreakerState) {
+				log.Printf("circuit breaker %v -> %v", from, to)
+			},
+		})
+		unary = append(unary, breaker.Unary())
+	}
+	unary = append(unary, interceptor.UnaryClientHedging(hedging))
+	opts := []grpc.DialOption{
+		grpc.WithStatsHandler(telemetry.ClientHandler(tp)),
+		grpc.WithResolvers(staticresolver.Static(), staticresolver.File(*resolvePoll)),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(sc),
+		grpc.WithChainUnaryInterceptor(unary...),
+	}
+	transport, err := transportOptions()
+	if err != nil {
+		log.Fatalf("failed to set up transport: %v", err)
+	}
+	opts = append(opts, transport...)
+	if *jwtTokenFile != "" {
+		token, err := os.ReadFile(*jwtTokenFile)
+		if err != nil {
+			log.Fatalf("failed to read token: %v", err)
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(auth.TokenCredentials{
+			Token:    strings.TrimSpace(string(token)),
+			Insecure: *plaintext,
+		}))
+	}
+	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
 	defer conn.Close()
 	c := pb.NewGreeterClient(conn)
 
-	// Contact the server and print out its response.
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	if *list {
+		if err := listGreetings(ctx, c); err != nil {
+			fatalStatus("could not list greetings", err)
+		}
+		return
+	}
+
+	if *bench {
+		if err := runBench(ctx, c); err != nil {
+			log.Fatalf("load test failed: %v", err)
+		}
+		return
+	}
+
+	if *chat {
+		if err := chatRoom(ctx, c); err != nil {
+			log.Fatalf("chat failed: %v", err)
+		}
+		return
+	}
+
+	// Contact the server and print out its response. Each call gets its own
+	// deadline so a slow first call can't starve the second.
+	served := make(map[string]int)
+	for range *repeat {
+		for _, greet := range []func(context.Context, *pb.HelloRequest, ...grpc.CallOption) (*pb.HelloReply, error){
+			c.SayHello, c.SayHelloAgain,
+		} {
+			callCtx, cancel := context.WithTimeout(ctx, *callTimeout)
+			r, err := greet(callCtx, &pb.HelloRequest{Name: *name, Language: *lang})
+			cancel()
+			if err != nil {
+				fatalStatus("could not greet", err)
+			}
+			served[r.GetInstanceId()]++
+			log.Printf("Greeting: %s (from %s, in %s)", r.GetMessage(), r.GetInstanceId(), r.GetLanguage())
+		}
+	}
+	if len(served) > 1 {
+		for id, n := range served {
+			log.Printf("%s served %d calls", id, n)
+		}
+	}
+
+	if err := streamGreetings(ctx, c); err != nil {
+		fatalStatus("could not stream greetings", err)
+	}
+}
+
+// fatalStatus logs err, followed by any field violations or retry delay the
+// server attached to it, and exits.
+func fatalStatus(msg string, err error) {
+	st := status.Convert(err)
+	log.Printf("%s: %v: %s", msg, st.Code(), st.Message())
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				log.Printf("  %s: %s", v.GetField(), v.GetDescription())
+			}
+		case *errdetails.RetryInfo:
+			log.Printf("  retry after %v", d.GetRetryDelay().AsDuration())
+		}
+	}
+	os.Exit(1)
+}
+
+// loadServiceConfig returns -service_config as-is when it is inline JSON,
+// otherwise the contents of the file it names.
+func loadServiceConfig() (string, error) {
+	if *serviceConfig == "" {
+		return defaultServiceConfig, nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(*serviceConfig), "{") {
+		return *serviceConfig, nil
+	}
+	b, err := os.ReadFile(*serviceConfig)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// clientCredentials returns TLS credentials from the -tls_* flags, or
+// plaintext when -insecure is set.
+func clientCredentials() (credentials.TransportCredentials, error) {
+	if *plaintext {
+		return insecure.NewCredentials(), nil
+	}
+	cfg, err := tlsutil.ClientConfig(*tlsCA, *tlsCert, *tlsKey, *tlsServerName)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(cfg), nil
+}
+
+// streamGreetings prints every greeting from SayHelloStream. The stream has
+// no deadline since it can outlive any unary timeout; cancelling its context
+// is how the client hangs up mid-stream.
+func streamGreetings(ctx context.Context, c pb.GreeterClient) error {
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	r, err := c.SayHello(ctx, &pb.HelloRequest{Name: *name})
+	stream, err := c.SayHelloStream(ctx, &pb.HelloStreamRequest{
+		Name:       *name,
+		Count:      int32(*streamCount),
+		IntervalMs: int32(streamInterval.Milliseconds()),
+	})
 	if err != nil {
-		log.Fatalf("could not greet: %v", err)
+		return err
+	}
+	for received := 0; ; {
+		r, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if status.Code(err) == codes.Canceled {
+			log.Printf("Stream cancelled after %d greetings", received)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		received++
+		log.Printf("Streamed greeting: %s (from %s)", r.GetMessage(), r.GetInstanceId())
+		if received == *cancelAfter {
+			cancel()
+		}
 	}
-	log.Printf("Greeting: %s", r.GetMessage())
+}
+
+// listGreetings pages through ListGreetings until the server returns no
+// next_page_token.
+func listGreetings(ctx context.Context, c pb.GreeterClient) error {
+	req := &pb.ListGreetingsRequest{Name: *listName, PageSize: int32(*listPageSize)}
+	for page := 1; ; page++ {
+		callCtx, cancel := context.WithTimeout(ctx, *callTimeout)
+		resp, err := c.ListGreetings(callCtx, req)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, g := range resp.GetGreetings() {
+			log.Printf("page %d: %s %q to %s", page,
+				g.GetCreateTime().AsTime().Format(time.RFC3339), g.GetMessage(), g.GetName())
+		}
+		if resp.GetNextPageToken() == "" {
+			return nil
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+}
 
-	r, err = c.SayHelloAgain(ctx, &pb.HelloRequest{Name: *name})
+// chatRoom sends each line read from stdin to the Chat stream and prints
+// what the other peers say. Closing stdin half-closes the stream; an
+// interrupt cancels it.
+func chatRoom(ctx context.Context, c pb.GreeterClient) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	stream, err := c.Chat(ctx)
 	if err != nil {
-		log.Fatalf("could not greet: %v", err)
+		return err
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if err := stream.Send(&pb.ChatMessage{Sender: *name, Text: scanner.Text()}); err != nil {
+				return
+			}
+		}
+		stream.CloseSend()
+	}()
+
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if status.Code(err) == codes.Canceled {
+			log.Printf("Left the chat")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("[%s] %s", msg.GetSender(), msg.GetText())
 	}
-	log.Printf("Greeting: %s", r.GetMessage())
 }
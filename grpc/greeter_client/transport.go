@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"grpc/zstd"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+)
+
+// transportOptions returns the dial options set by the compression,
+// message size and keepalive flags.
+func transportOptions() ([]grpc.DialOption, error) {
+	var callOpts []grpc.CallOption
+	switch *compression {
+	case "", "none":
+	case gzip.Name, zstd.Name:
+		callOpts = append(callOpts, grpc.UseCompressor(*compression))
+	default:
+		return nil, fmt.Errorf("unknown compression %q; want none, gzip or zstd", *compression)
+	}
+	// Oversized messages fail with RESOURCE_EXHAUSTED before they are
+	// sent, or when they arrive.
+	if *maxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(*maxRecvMsgSize))
+	}
+	if *maxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(*maxSendMsgSize))
+	}
+	opts := []grpc.DialOption{grpc.WithDefaultCallOptions(callOpts...)}
+	// The server's -keepalive_min_time and -keepalive_permit_without_stream
+	// must allow these pings, or it closes the connection.
+	if *keepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                *keepaliveTime,
+			Timeout:             *keepaliveTimeout,
+			PermitWithoutStream: *keepalivePermitWithoutStream,
+		}))
+	}
+	return opts, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"grpc/gateway"
+	pb "grpc/helloworld"
+	"grpc/tlsutil"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	httpAddr  = flag.String("http_addr", ":8081", "The address to serve HTTP/JSON on")
+	addr      = flag.String("addr", "localhost:50051", "The greeter server to forward to")
+	tlsCA     = flag.String("tls_ca", "", "CA bundle used to verify the greeter server (PEM); system roots when empty")
+	plaintext = flag.Bool("insecure", false, "Connect to the greeter server without TLS; only for local development")
+)
+
+func main() {
+	flag.Parse()
+	creds := insecure.NewCredentials()
+	if !*plaintext {
+		cfg, err := tlsutil.ClientConfig(*tlsCA, "", "", "")
+		if err != nil {
+			log.Fatalf("failed to load credentials: %v", err)
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Fatalf("did not connect: %v", err)
+	}
+	defer conn.Close()
+
+	log.Printf("gateway listening at %v, forwarding to %v", *httpAddr, *addr)
+	if err := http.ListenAndServe(*httpAddr, gateway.New(pb.NewGreeterClient(conn))); err != nil {
+		log.Fatalf("failed to serve: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"sync"
+
+	pb "grpc/helloworld"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// chatPeer is one connected Chat stream. Messages for it are queued on out
+// and written to the stream by its own goroutine, so a slow client never
+// blocks the sender that is broadcasting.
+type chatPeer struct {
+	addr    string
+	out     chan *pb.ChatMessage
+	evicted bool
+}
+
+// chatRoom tracks the connected peers and fans messages out to them.
+type chatRoom struct {
+	mu     sync.Mutex
+	peers  map[*chatPeer]struct{}
+	buffer int
+}
+
+func newChatRoom(buffer int) *chatRoom {
+	return &chatRoom{peers: make(map[*chatPeer]struct{}), buffer: buffer}
+}
+
+func (r *chatRoom) join(addr string) *chatPeer {
+	p := &chatPeer{addr: addr, out: make(chan *pb.ChatMessage, r.buffer)}
+	r.mu.Lock()
+	r.peers[p] = struct{}{}
+	n := len(r.peers)
+	r.mu.Unlock()
+	log.Printf("Chat: %v joined, %d connected", addr, n)
+	return p
+}
+
+func (r *chatRoom) leave(p *chatPeer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(p)
+}
+
+func (r *chatRoom) removeLocked(p *chatPeer) {
+	if _, ok := r.peers[p]; !ok {
+		return
+	}
+	delete(r.peers, p)
+	close(p.out)
+	log.Printf("Chat: %v left, %d connected", p.addr, len(r.peers))
+}
+
+// broadcast queues msg for every peer except from. A peer whose queue is
+// full is evicted instead of letting it hold up everybody else.
+func (r *chatRoom) broadcast(from *chatPeer, msg *pb.ChatMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for p := range r.peers {
+		if p == from {
+			continue
+		}
+		select {
+		case p.out <- msg:
+		default:
+			log.Printf("Chat: %v is not keeping up, disconnecting", p.addr)
+			p.evicted = true
+			r.removeLocked(p)
+		}
+	}
+}
+
+// pump writes queued messages to the stream until the peer leaves the room.
+func (p *chatPeer) pump(stream pb.Greeter_ChatServer) error {
+	for msg := range p.out {
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	if p.evicted {
+		return status.Error(codes.ResourceExhausted, "chat: too many undelivered messages")
+	}
+	return nil
+}
+
+func (s *server) Chat(stream pb.Greeter_ChatServer) error {
+	addr := "unknown"
+	if pr, ok := peer.FromContext(stream.Context()); ok {
+		addr = pr.Addr.String()
+	}
+	p := s.chat.join(addr)
+
+	sendDone := make(chan error, 1)
+	go func() { sendDone <- p.pump(stream) }()
+
+	recvDone := make(chan error, 1)
+	go func() {
+		for {
+			in, err := stream.Recv()
+			if err != nil {
+				recvDone <- err
+				return
+			}
+			s.chat.broadcast(p, in)
+		}
+	}()
+
+	select {
+	case err := <-recvDone:
+		// Leaving closes p.out, which lets pump flush what is queued and
+		// return before the handler does; Send must not outlive the handler.
+		s.chat.leave(p)
+		if sendErr := <-sendDone; sendErr != nil {
+			return sendErr
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	case err := <-sendDone:
+		s.chat.leave(p)
+		return err
+	}
+}
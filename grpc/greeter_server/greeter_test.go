@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"grpc/greetertest"
+	pb "grpc/helloworld"
+	"grpc/history"
+	"grpc/i18n"
+	"grpc/interceptor"
+
+	"google.golang.org/grpc"
+)
+
+func TestGreeter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h, err := greetertest.Start(newServer(history.NewMemoryStore(100), i18n.Builtin()),
+		greetertest.WithUnaryInterceptors(interceptor.UnaryRequestID(), interceptor.UnaryRecovery(logger)),
+		greetertest.WithStreamInterceptors(interceptor.StreamRequestID(), interceptor.StreamRecovery(logger)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	for _, tc := range greetertest.Cases() {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := h.Check(tc); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestGreeterSizeLimits(t *testing.T) {
+	const limit = 1024
+	h, err := greetertest.Start(newServer(history.NewMemoryStore(100), i18n.Builtin()),
+		greetertest.WithServerOptions(grpc.MaxRecvMsgSize(limit)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	for _, tc := range greetertest.SizeLimitCases(limit) {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := h.Check(tc); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCheckStreamFlags(t *testing.T) {
+	defer func(d time.Duration) { *streamInterval = d }(*streamInterval)
+	for _, d := range []time.Duration{0, -time.Second} {
+		*streamInterval = d
+		if err := checkStreamFlags(); err == nil {
+			t.Errorf("-stream_interval=%v accepted, want an error", d)
+		}
+	}
+	*streamInterval = time.Millisecond
+	if err := checkStreamFlags(); err != nil {
+		t.Errorf("-stream_interval=1ms rejected: %v", err)
+	}
+}
+
+func TestListGreetingsHidesPeers(t *testing.T) {
+	h, err := greetertest.Start(newServer(history.NewMemoryStore(100), i18n.Builtin()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	ctx := context.Background()
+	if _, err := h.Client.SayHello(ctx, &pb.HelloRequest{Name: "Ana"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := h.Client.ListGreetings(ctx, &pb.ListGreetingsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.GetGreetings()) != 1 {
+		t.Fatalf("ListGreetings returned %d greetings, want 1", len(resp.GetGreetings()))
+	}
+	if peer := resp.GetGreetings()[0].GetPeer(); peer != "" {
+		t.Errorf("ListGreetings revealed peer %q, want it left empty", peer)
+	}
+}
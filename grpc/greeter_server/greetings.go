@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	pb "grpc/helloworld"
+	"grpc/history"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageToken is what next_page_token encodes. The filter is included so a
+// token can't be replayed against a different query.
+type pageToken struct {
+	AfterID int64  `json:"after_id"`
+	Name    string `json:"name,omitempty"`
+}
+
+func (t pageToken) encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodePageToken(s string) (pageToken, error) {
+	var t pageToken
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, err
+	}
+	err = json.Unmarshal(b, &t)
+	return t, err
+}
+
+// record stores a greeting that was sent. Failing to record it is logged
+// rather than failing the greeting itself.
+func (s *server) record(ctx context.Context, name, message string) {
+	addr := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+	g := history.Greeting{Name: name, Message: message, Peer: addr, CreatedAt: time.Now()}
+	if err := s.history.Add(ctx, g); err != nil {
+		log.Printf("failed to record greeting for %v: %v", name, err)
+	}
+}
+
+func (s *server) ListGreetings(ctx context.Context, in *pb.ListGreetingsRequest) (*pb.ListGreetingsResponse, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if in.GetPageSize() < 0 {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "page_size",
+			Description: fmt.Sprintf("must not be negative, got %d", in.GetPageSize()),
+		})
+	}
+	if in.GetName() != "" {
+		violations = append(violations, validateName("name", in.GetName())...)
+	}
+	var token pageToken
+	if in.GetPageToken() != "" {
+		t, err := decodePageToken(in.GetPageToken())
+		switch {
+		case err != nil:
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: "page_token", Description: "is not a valid page token"})
+		case t.Name != in.GetName():
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: "page_token", Description: "was issued for a different name filter"})
+		default:
+			token = t
+		}
+	}
+	if err := invalidArgument(violations); err != nil {
+		return nil, err
+	}
+
+	size := int(in.GetPageSize())
+	if size == 0 {
+		size = defaultPageSize
+	}
+	size = min(size, maxPageSize)
+
+	// Ask for one extra to learn whether there is another page.
+	page, err := s.history.List(ctx, history.Query{Name: in.GetName(), AfterID: token.AfterID, Limit: size + 1})
+	if err != nil {
+		log.Printf("failed to list greetings: %v", err)
+		return nil, status.Error(codes.Internal, "list greetings failed")
+	}
+	resp := &pb.ListGreetingsResponse{}
+	if len(page) > size {
+		page = page[:size]
+		resp.NextPageToken = pageToken{AfterID: page[size-1].ID, Name: in.GetName()}.encode()
+	}
+	for _, g := range page {
+		resp.Greetings = append(resp.Greetings, &pb.Greeting{
+			Name:       g.Name,
+			Message:    g.Message,
+			CreateTime: timestamppb.New(g.CreatedAt),
+			// Peer is left out: it would show every caller where the
+			// others connect from.
+		})
+	}
+	return resp, nil
+}
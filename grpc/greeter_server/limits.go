@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"grpc/auth"
+	pb "grpc/helloworld"
+	"grpc/ratelimit"
+)
+
+// newLimiter builds the rate limiter from the -rate_* and -method_limits
+// flags. Health checks are never limited so a busy server isn't mistaken
+// for a dead one.
+func newLimiter() (*ratelimit.Limiter, error) {
+	methods, err := parseMethodLimits(*methodLimits)
+	if err != nil {
+		return nil, err
+	}
+	defaultLimit := ratelimit.Limit{Rate: *rateLimit, Burst: *rateBurst, MaxInFlight: *maxInFlight}
+	if err := checkLimit(defaultLimit); err != nil {
+		return nil, fmt.Errorf("-rate_limit, -rate_burst, -max_in_flight: %w", err)
+	}
+	return ratelimit.New(ratelimit.Config{
+		Default: defaultLimit,
+		Methods: methods,
+		Exempt:  []string{"/grpc.health.v1.Health/"},
+		Key:     callerKey,
+	}), nil
+}
+
+// callerKey charges authenticated calls to the token subject, wherever they
+// come from, and anonymous calls to the peer's IP address.
+func callerKey(ctx context.Context) string {
+	if claims, ok := auth.ClaimsFromContext(ctx); ok && claims.Subject != "" {
+		return "sub:" + claims.Subject
+	}
+	return "ip:" + ratelimit.PeerKey(ctx)
+}
+
+// parseMethodLimits parses "Method=rate:burst[:max_in_flight],...". Bare
+// method names refer to the Greeter service.
+func parseMethodLimits(s string) (map[string]ratelimit.Limit, error) {
+	limits := make(map[string]ratelimit.Limit)
+	if s == "" {
+		return limits, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		method, spec, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		parts := strings.Split(spec, ":")
+		if !ok || len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("method limit %q is not Method=rate:burst[:max_in_flight]", entry)
+		}
+		var lim ratelimit.Limit
+		var err error
+		if lim.Rate, err = strconv.ParseFloat(parts[0], 64); err != nil {
+			return nil, fmt.Errorf("method limit %q: rate: %w", entry, err)
+		}
+		if lim.Burst, err = strconv.Atoi(parts[1]); err != nil {
+			return nil, fmt.Errorf("method limit %q: burst: %w", entry, err)
+		}
+		if len(parts) == 3 {
+			if lim.MaxInFlight, err = strconv.Atoi(parts[2]); err != nil {
+				return nil, fmt.Errorf("method limit %q: max_in_flight: %w", entry, err)
+			}
+		}
+		if err := checkLimit(lim); err != nil {
+			return nil, fmt.Errorf("method limit %q: %w", entry, err)
+		}
+		if !strings.HasPrefix(method, "/") {
+			method = "/" + pb.Greeter_ServiceDesc.ServiceName + "/" + method
+		}
+		limits[method] = lim
+	}
+	return limits, nil
+}
+
+// checkLimit rejects limits that can't be enforced sensibly. A bucket whose
+// burst is below one never holds a whole token, so a rate with no burst
+// would refuse every call with a retry-after that never comes true.
+func checkLimit(lim ratelimit.Limit) error {
+	switch {
+	case !(lim.Rate >= 0) || math.IsInf(lim.Rate, 0):
+		return fmt.Errorf("rate must be a non-negative number, got %g", lim.Rate)
+	case lim.Burst < 0:
+		return fmt.Errorf("burst must not be negative, got %d", lim.Burst)
+	case lim.MaxInFlight < 0:
+		return fmt.Errorf("max_in_flight must not be negative, got %d", lim.MaxInFlight)
+	case lim.Rate > 0 && lim.Burst < 1:
+		return fmt.Errorf("burst must be at least 1 when rate is set, got %d", lim.Burst)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	pb "grpc/helloworld"
+	"grpc/i18n"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// acceptLanguageKey is the metadata key consulted when a request does not
+// name a language. The gateway forwards the HTTP header under it.
+const acceptLanguageKey = "accept-language"
+
+// loadCatalog returns the catalog named by -messages, or the built-in one.
+func loadCatalog() (*i18n.Catalog, error) {
+	if *messages == "" {
+		return i18n.Builtin(), nil
+	}
+	data, err := os.ReadFile(*messages)
+	if err != nil {
+		return nil, err
+	}
+	return i18n.Parse(data)
+}
+
+// languagePreferences lists the languages the caller would like, best
+// first: the one in the request, then those in accept-language.
+func languagePreferences(ctx context.Context, requested string) []string {
+	var prefs []string
+	if requested != "" {
+		prefs = append(prefs, requested)
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		prefs = append(prefs, i18n.ParseAcceptLanguage(strings.Join(md.Get(acceptLanguageKey), ","))...)
+	}
+	return prefs
+}
+
+// localize renders the catalog message key for in's name and returns it
+// with the language it ended up in.
+func (s *server) localize(ctx context.Context, in *pb.HelloRequest, key string, count int) (string, string) {
+	message, lang, ok := s.catalog.Format(languagePreferences(ctx, in.GetLanguage()), key, count, map[string]string{"name": in.GetName()})
+	if !ok {
+		// A custom catalog without the key; don't leave the caller with nothing.
+		log.Printf("message catalog has no %q message", key)
+		return "Hello " + in.GetName(), ""
+	}
+	return message, lang
+}
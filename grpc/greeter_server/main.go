@@ -2,43 +2,404 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"grpc/auth"
 	pb "grpc/helloworld"
+	"grpc/history"
+	"grpc/i18n"
+	"grpc/interceptor"
+	"grpc/telemetry"
+	"grpc/tlsutil"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	channelzservice "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	port = flag.Int("port", 50051, "The server port")
+	port       = flag.Int("port", 50051, "The server port")
+	instanceID = flag.String("instance_id", "", "ID reported in every HelloReply; defaults to hostname:port")
+
+	tlsCert       = flag.String("tls_cert", "", "Server certificate file (PEM)")
+	tlsKey        = flag.String("tls_key", "", "Server private key file (PEM)")
+	tlsCA         = flag.String("tls_ca", "", "CA bundle used to verify client certificates (PEM)")
+	tlsClientAuth = flag.Bool("tls_client_auth", false, "Require clients to present a certificate signed by -tls_ca (mutual TLS)")
+	plaintext     = flag.Bool("insecure", false, "Serve without TLS; only for local development")
+
+	streamCount    = flag.Int("stream_count", 5, "Default number of greetings sent by SayHelloStream")
+	streamMaxCount = flag.Int("stream_max_count", 100, "Maximum number of greetings a client may request from SayHelloStream")
+	streamInterval = flag.Duration("stream_interval", 500*time.Millisecond, "Default pause between greetings sent by SayHelloStream")
+
+	jwtKey      = flag.String("jwt_key", "", "Verify bearer JWTs with this key: a PEM RSA public key (RS256) or an HMAC secret file (HS256); auth is off when empty")
+	jwtIssuer   = flag.String("jwt_issuer", "", "Required token issuer, if set")
+	jwtAudience = flag.String("jwt_audience", "", "Required token audience, if set")
+
+	rateLimit            = flag.Float64("rate_limit", 0, "Calls per second allowed per caller and method; 0 disables rate limiting")
+	rateBurst            = flag.Int("rate_burst", 10, "Calls a caller may burst above -rate_limit")
+	maxInFlight          = flag.Int("max_in_flight", 0, "Calls per caller and method running at once; 0 is unlimited")
+	methodLimits         = flag.String("method_limits", "", "Per-method overrides as Method=rate:burst[:max_in_flight],... e.g. SayHello=5:10,Chat=0:0:20")
+	maxConcurrentStreams = flag.Uint("max_concurrent_streams", 0, "Streams a single connection may have open at once; 0 keeps the gRPC default")
+
+	maxRecvMsgSize = flag.Int("max_recv_msg_size", 0, "Largest request message in bytes, after decompression; larger ones fail with RESOURCE_EXHAUSTED. 0 keeps the gRPC default of 4 MiB")
+	maxSendMsgSize = flag.Int("max_send_msg_size", 0, "Largest reply message in bytes; 0 keeps the gRPC default, which is unlimited")
+	compression    = flag.String("compression", "none", "Compress replies with none, gzip or zstd when the client accepts it; with none, replies are compressed like the request")
+
+	keepaliveMinTime             = flag.Duration("keepalive_min_time", 5*time.Minute, "Disconnect clients that send keepalive pings more often than this")
+	keepalivePermitWithoutStream = flag.Bool("keepalive_permit_without_stream", false, "Allow keepalive pings on connections with no active calls")
+	keepaliveTime                = flag.Duration("keepalive_time", 0, "Ping clients after a connection has been idle this long; 0 keeps the gRPC default of 2h")
+	keepaliveTimeout             = flag.Duration("keepalive_timeout", 0, "Close the connection if a ping is not answered within this; 0 keeps the gRPC default of 20s")
+	maxConnectionIdle            = flag.Duration("max_connection_idle", 0, "Close connections with no calls for this long; 0 never does")
+	maxConnectionAge             = flag.Duration("max_connection_age", 0, "Close connections this old, so clients reconnect and rebalance; 0 never does")
+	maxConnectionAgeGrace        = flag.Duration("max_connection_age_grace", 0, "Time given to calls still running when -max_connection_age is reached; 0 waits for them")
+
+	metricsAddr   = flag.String("metrics_addr", ":9090", "Address to serve Prometheus /metrics on; empty disables it")
+	traceExporter = flag.String("trace_exporter", "none", "Where to send OpenTelemetry spans: none or stdout")
+
+	shutdownTimeout = flag.Duration("shutdown_timeout", 10*time.Second, "How long to wait for in-flight RPCs on shutdown before cancelling them")
+
+	failPercent = flag.Float64("fail_percent", 0, "Fail this percentage of unary Greeter calls with UNAVAILABLE to exercise client retries")
+
+	historyStore    = flag.String("history_store", "memory", "Where greetings are recorded: memory or sqlite")
+	historyDB       = flag.String("history_db", "greetings.db", "SQLite database file used by -history_store=sqlite")
+	historyCapacity = flag.Int("history_capacity", 10000, "Greetings -history_store=memory keeps before dropping the oldest")
+
+	chatBuffer = flag.Int("chat_buffer", 32, "Messages queued per Chat peer before it is disconnected as too slow")
+
+	serveWeb    = flag.Bool("web", false, "Also accept gRPC-Web and Connect calls over HTTP/1.1 and h2c on -port; native gRPC then goes through net/http")
+	corsOrigins = flag.String("cors_origins", "", "Comma-separated origins browsers may call -web from, or * for any; empty allows same-origin pages only")
+
+	messages = flag.String("messages", "", "JSON message catalog to greet with instead of the built-in one")
 )
 
 type server struct {
 	pb.UnimplementedGreeterServer
+
+	instanceID string
+	chat       *chatRoom
+	history    history.Store
+	catalog    *i18n.Catalog
+}
+
+func newServer(store history.Store, catalog *i18n.Catalog) *server {
+	id := *instanceID
+	if id == "" {
+		host, _ := os.Hostname()
+		id = fmt.Sprintf("%s:%d", host, *port)
+	}
+	return &server{instanceID: id, chat: newChatRoom(*chatBuffer), history: store, catalog: catalog}
 }
 
-func (s *server) SayHello(_ context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
-	log.Printf("Received: %v", in.GetName())
-	return &pb.HelloReply{Message: "Hello " + in.GetName()}, nil
+// openHistory returns the greeting store selected by -history_store.
+func openHistory() (history.Store, error) {
+	switch *historyStore {
+	case "memory":
+		if *historyCapacity <= 0 {
+			return nil, fmt.Errorf("-history_capacity must be positive, got %d", *historyCapacity)
+		}
+		return history.NewMemoryStore(*historyCapacity), nil
+	case "sqlite":
+		return history.OpenSQLite(*historyDB)
+	default:
+		return nil, fmt.Errorf("unknown history store %q", *historyStore)
+	}
+}
+
+func (s *server) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	if err := validateHelloRequest(in); err != nil {
+		return nil, err
+	}
+	caller := "anonymous"
+	if claims, ok := auth.ClaimsFromContext(ctx); ok {
+		caller = claims.Subject
+	}
+	log.Printf("Received: %v from %s (request %s)", in.GetName(), caller, interceptor.RequestIDFromContext(ctx))
+	message, lang := s.localize(ctx, in, "hello", 1)
+	reply := &pb.HelloReply{Message: message, InstanceId: s.instanceID, Language: lang}
+	s.record(ctx, in.GetName(), reply.GetMessage())
+	return reply, nil
 }
 
 func (s *server) SayHelloAgain(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
-	return &pb.HelloReply{Message: "Hello again " + in.GetName()}, nil
+	if err := validateHelloRequest(in); err != nil {
+		return nil, err
+	}
+	// The reply counts this greeting along with the ones already recorded.
+	n, err := s.history.Count(ctx, in.GetName())
+	if err != nil {
+		log.Printf("failed to count greetings for %v: %v", in.GetName(), err)
+	}
+	message, lang := s.localize(ctx, in, "hello_again", n+1)
+	reply := &pb.HelloReply{Message: message, InstanceId: s.instanceID, Language: lang}
+	s.record(ctx, in.GetName(), reply.GetMessage())
+	return reply, nil
+}
+
+// SayHelloStream sends in.Count greetings, one every in.IntervalMs. Send
+// blocks once the client's flow-control window is full, so a slow reader
+// paces the loop on its own; the ticker only sets the fastest rate.
+func (s *server) SayHelloStream(in *pb.HelloStreamRequest, stream pb.Greeter_SayHelloStreamServer) error {
+	if err := validateHelloStreamRequest(in); err != nil {
+		return err
+	}
+	count := int(in.GetCount())
+	if count == 0 {
+		count = *streamCount
+	}
+	interval := time.Duration(in.GetIntervalMs()) * time.Millisecond
+	if interval == 0 {
+		interval = *streamInterval
+	}
+
+	log.Printf("Streaming %d greetings to %v every %v", count, in.GetName(), interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for i := 1; i <= count; i++ {
+		reply := &pb.HelloReply{Message: fmt.Sprintf("Hello %s #%d", in.GetName(), i), InstanceId: s.instanceID}
+		if err := stream.Send(reply); err != nil {
+			return err
+		}
+		s.record(stream.Context(), in.GetName(), reply.GetMessage())
+		if i == count {
+			break
+		}
+		select {
+		case <-stream.Context().Done():
+			log.Printf("Stream to %v stopped after %d greetings: %v", in.GetName(), i, stream.Context().Err())
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// checkStreamFlags rejects -stream_* values SayHelloStream can't use. The
+// interval drives a ticker, which needs it to be positive.
+func checkStreamFlags() error {
+	if *streamInterval <= 0 {
+		return fmt.Errorf("-stream_interval must be positive, got %v", *streamInterval)
+	}
+	return nil
+}
+
+// serverTLSConfig returns the TLS configuration from the -tls_* flags, or
+// nil with -insecure. Serving in plaintext has to be asked for explicitly.
+func serverTLSConfig() (*tls.Config, error) {
+	if *plaintext {
+		return nil, nil
+	}
+	if *tlsCert == "" || *tlsKey == "" {
+		return nil, fmt.Errorf("-tls_cert and -tls_key are required unless -insecure is set")
+	}
+	return tlsutil.ServerConfig(*tlsCert, *tlsKey, *tlsCA, *tlsClientAuth)
+}
+
+// newAuthenticator requires a valid token on every call except health
+// checks, and a scope per Greeter method.
+func newAuthenticator() (*auth.Authenticator, error) {
+	key, err := auth.LoadVerificationKey(*jwtKey)
+	if err != nil {
+		return nil, err
+	}
+	policy := auth.Policy{
+		Public: []string{"/grpc.health.v1.Health/"},
+		Scopes: map[string]string{
+			pb.Greeter_SayHello_FullMethodName:       "greeter.hello",
+			pb.Greeter_SayHelloAgain_FullMethodName:  "greeter.hello",
+			pb.Greeter_SayHelloStream_FullMethodName: "greeter.hello",
+			pb.Greeter_Chat_FullMethodName:           "greeter.chat",
+			pb.Greeter_ListGreetings_FullMethodName:  "greeter.history",
+		},
+	}
+	return auth.NewAuthenticator(key, policy, *jwtIssuer, *jwtAudience), nil
 }
 
 func main() {
 	flag.Parse()
+	if err := checkStreamFlags(); err != nil {
+		log.Fatalf("invalid flags: %v", err)
+	}
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
-	pb.RegisterGreeterServer(s, &server{})
+	tlsConfig, err := serverTLSConfig()
+	if err != nil {
+		log.Fatalf("failed to load credentials: %v", err)
+	}
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	exporter, err := telemetry.NewExporter(*traceExporter)
+	if err != nil {
+		log.Fatalf("failed to set up tracing: %v", err)
+	}
+	tp := telemetry.NewTracerProvider("greeter_server", exporter)
+	defer telemetry.Shutdown(context.Background(), tp)
+	metrics := telemetry.NewServerMetrics(prometheus.DefaultRegisterer)
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	inflight := &interceptor.InFlight{}
+	unary := []grpc.UnaryServerInterceptor{
+		inflight.Unary(),
+		metrics.Unary(),
+		interceptor.UnaryRequestID(),
+		interceptor.UnaryLogging(logger),
+		interceptor.UnaryRecovery(logger),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		inflight.Stream(),
+		metrics.Stream(),
+		interceptor.StreamRequestID(),
+		interceptor.StreamLogging(logger),
+		interceptor.StreamRecovery(logger),
+	}
+	if *jwtKey != "" {
+		authn, err := newAuthenticator()
+		if err != nil {
+			log.Fatalf("failed to set up authentication: %v", err)
+		}
+		unary = append(unary, authn.Unary())
+		stream = append(stream, authn.Stream())
+	}
+	limiter, err := newLimiter()
+	if err != nil {
+		log.Fatalf("failed to set up rate limiting: %v", err)
+	}
+	unary = append(unary, limiter.Unary(), interceptor.UnaryFaultInjection(*failPercent, codes.Unavailable))
+	stream = append(stream, limiter.Stream())
+	compressUnary, compressStream, err := compressionInterceptors()
+	if err != nil {
+		log.Fatalf("failed to set up compression: %v", err)
+	}
+	if compressUnary != nil {
+		unary = append(unary, compressUnary)
+		stream = append(stream, compressStream)
+	}
+	opts := []grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.StatsHandler(telemetry.ServerHandler(tp)),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+	if *maxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(uint32(*maxConcurrentStreams)))
+	}
+	opts = append(opts, transportOptions()...)
+	s := grpc.NewServer(opts...)
+	store, err := openHistory()
+	if err != nil {
+		log.Fatalf("failed to open greeting history: %v", err)
+	}
+	defer store.Close()
+	catalog, err := loadCatalog()
+	if err != nil {
+		log.Fatalf("failed to load message catalog: %v", err)
+	}
+	pb.RegisterGreeterServer(s, newServer(store, catalog))
+
+	// Health, reflection and channelz let orchestrators probe the server and
+	// grpcurl/grpcdebug inspect it without a copy of helloworld.proto.
+	healthcheck := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthcheck)
+	healthcheck.SetServingStatus(pb.Greeter_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	reflection.Register(s)
+	channelzservice.RegisterChannelzServiceToServer(s)
+
+	var web *webServer
+	if *serveWeb {
+		if web, err = newWebServer(s, tlsConfig); err != nil {
+			log.Fatalf("failed to set up gRPC-Web: %v", err)
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-sigs
+		log.Printf("received %v, shutting down", sig)
+		shutdown(s, web, healthcheck, inflight, *shutdownTimeout)
+	}()
+
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		go func() {
+			log.Printf("metrics listening at %v", *metricsAddr)
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				log.Printf("metrics server stopped: %v", err)
+			}
+		}()
+	}
+
 	log.Printf("server listening at %v", lis.Addr())
-	if err := s.Serve(lis); err != nil {
+	if web != nil {
+		err = serveWebServer(web, lis)
+	} else {
+		err = s.Serve(lis)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("failed to serve: %v", err)
 	}
+	// Serve returns as soon as the listener closes; wait for the drain.
+	<-stopped
+}
+
+// shutdown reports NOT_SERVING so health checkers stop routing new calls
+// here, then lets in-flight RPCs finish for up to timeout before cancelling
+// whatever is left. web, if not nil, is the HTTP server fronting s.
+func shutdown(s *grpc.Server, web *webServer, healthcheck *health.Server, inflight *interceptor.InFlight, timeout time.Duration) {
+	healthcheck.Shutdown()
+	pending := inflight.Count()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var err error
+	if web != nil {
+		// Every call reaches s through web's handler, and GracefulStop
+		// panics on those transports since they can't be drained. web
+		// drains them instead.
+		err = web.Shutdown(ctx)
+	} else {
+		done := make(chan struct{})
+		go func() {
+			s.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if err == nil {
+		s.Stop()
+		log.Printf("drained %d in-flight RPCs", pending)
+		return
+	}
+	aborted := inflight.Count()
+	s.Stop()
+	log.Printf("drain timed out after %v: drained %d in-flight RPCs, cancelled %d", timeout, pending-aborted, aborted)
 }
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"grpc/greetertest"
+	pb "grpc/helloworld"
+	"grpc/history"
+	"grpc/i18n"
+	"grpc/interceptor"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// startSlow starts the greeter with unary calls held up for delay, and
+// returns a channel that is closed once the first call is in flight.
+func startSlow(t *testing.T, delay time.Duration) (*greetertest.Harness, *health.Server, *interceptor.InFlight, <-chan struct{}) {
+	t.Helper()
+	inflight := &interceptor.InFlight{}
+	healthcheck := health.NewServer()
+	started := make(chan struct{})
+	var once sync.Once
+	slow := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		once.Do(func() { close(started) })
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return handler(ctx, req)
+	}
+	h, err := greetertest.Start(newServer(history.NewMemoryStore(100), i18n.Builtin()),
+		greetertest.WithUnaryInterceptors(inflight.Unary(), slow),
+		greetertest.WithService(&healthpb.Health_ServiceDesc, healthcheck),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h, healthcheck, inflight, started
+}
+
+func sayHelloAsync(h *greetertest.Harness) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := h.Client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ana"})
+		done <- err
+	}()
+	return done
+}
+
+func TestShutdownDrainsInFlightCalls(t *testing.T) {
+	h, healthcheck, inflight, started := startSlow(t, 200*time.Millisecond)
+	done := sayHelloAsync(h)
+	<-started
+
+	shutdown(h.Server, nil, healthcheck, inflight, 5*time.Second)
+
+	if err := <-done; err != nil {
+		t.Errorf("in-flight SayHello failed during shutdown: %v", err)
+	}
+	resp, err := healthcheck.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("health status after shutdown is %v, want NOT_SERVING", resp.GetStatus())
+	}
+}
+
+func TestShutdownCancelsCallsPastTheTimeout(t *testing.T) {
+	h, healthcheck, inflight, started := startSlow(t, time.Minute)
+	done := sayHelloAsync(h)
+	<-started
+
+	shutdown(h.Server, nil, healthcheck, inflight, 100*time.Millisecond)
+
+	if code := status.Code(<-done); code == codes.OK {
+		t.Error("SayHello succeeded, want it cut off by the shutdown timeout")
+	}
+}
+
+// startWeb serves the greeter through newWebServer on a loopback port and
+// connects a native gRPC client to it, which goes over h2c.
+func startWeb(t *testing.T) (*grpc.Server, *webServer, *health.Server, *interceptor.InFlight, pb.GreeterClient) {
+	t.Helper()
+	inflight := &interceptor.InFlight{}
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(inflight.Unary()), grpc.ChainStreamInterceptor(inflight.Stream()))
+	pb.RegisterGreeterServer(s, newServer(history.NewMemoryStore(100), i18n.Builtin()))
+	healthcheck := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthcheck)
+	web, err := newWebServer(s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serveWebServer(web, lis)
+	t.Cleanup(func() { web.Close() })
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return s, web, healthcheck, inflight, pb.NewGreeterClient(conn)
+}
+
+// streamAsync starts SayHelloStream and returns once the first greeting
+// has arrived, with a channel that gets how many greetings arrived in all
+// and the error the stream ended with.
+func streamAsync(t *testing.T, c pb.GreeterClient, count, intervalMs int32) <-chan streamResult {
+	t.Helper()
+	stream, err := c.SayHelloStream(context.Background(), &pb.HelloStreamRequest{Name: "Ana", Count: count, IntervalMs: intervalMs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("first greeting: %v", err)
+	}
+	done := make(chan streamResult, 1)
+	go func() {
+		n := 1
+		for {
+			if _, err := stream.Recv(); err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				done <- streamResult{n, err}
+				return
+			}
+			n++
+		}
+	}()
+	return done
+}
+
+type streamResult struct {
+	received int
+	err      error
+}
+
+func TestShutdownWebDrainsInFlightStream(t *testing.T) {
+	s, web, healthcheck, inflight, client := startWeb(t)
+	done := streamAsync(t, client, 5, 50)
+
+	shutdown(s, web, healthcheck, inflight, 5*time.Second)
+
+	r := <-done
+	if r.err != nil || r.received != 5 {
+		t.Errorf("stream ended after %d greetings with %v, want all 5 and OK", r.received, r.err)
+	}
+	if n := inflight.Count(); n != 0 {
+		t.Errorf("%d calls still in flight after shutdown", n)
+	}
+}
+
+func TestShutdownWebCancelsStreamPastTheTimeout(t *testing.T) {
+	s, web, healthcheck, inflight, client := startWeb(t)
+	done := streamAsync(t, client, 100, 1000)
+
+	start := time.Now()
+	shutdown(s, web, healthcheck, inflight, 200*time.Millisecond)
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("shutdown took %v, want it to give up after the 200ms timeout", d)
+	}
+
+	select {
+	case r := <-done:
+		if r.err == nil {
+			t.Errorf("stream finished OK after %d greetings, want it cut off", r.received)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("stream still running after shutdown")
+	}
+}
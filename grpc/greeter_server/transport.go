@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"grpc/interceptor"
+	"grpc/zstd"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+)
+
+// transportOptions returns the server options set by the message size and
+// keepalive flags. They apply to s's own transport only; with -web, HTTP/2
+// connections belong to net/http and only the size limits still hold.
+func transportOptions() []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if *maxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(*maxRecvMsgSize))
+	}
+	if *maxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(*maxSendMsgSize))
+	}
+	// Clients pinging more often than MinTime are sent GOAWAY
+	// ENHANCE_YOUR_CALM and disconnected.
+	opts = append(opts,
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             *keepaliveMinTime,
+			PermitWithoutStream: *keepalivePermitWithoutStream,
+		}),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     *maxConnectionIdle,
+			MaxConnectionAge:      *maxConnectionAge,
+			MaxConnectionAgeGrace: *maxConnectionAgeGrace,
+			Time:                  *keepaliveTime,
+			Timeout:               *keepaliveTimeout,
+		}),
+	)
+	return opts
+}
+
+// compressionInterceptors returns the interceptors that compress replies
+// with -compression, or nil when replies follow the request's compression,
+// which is what gRPC does on its own.
+func compressionInterceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor, error) {
+	switch *compression {
+	case "", "none":
+		return nil, nil, nil
+	case gzip.Name, zstd.Name:
+		return interceptor.UnarySendCompressor(*compression), interceptor.StreamSendCompressor(*compression), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown compression %q; want none, gzip or zstd", *compression)
+	}
+}
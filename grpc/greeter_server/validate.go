@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	pb "grpc/helloworld"
+	"grpc/i18n"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxNameLength = 64
+
+// validateName checks a name is present, at most maxNameLength characters
+// and made of letters, spaces, hyphens, apostrophes and periods.
+func validateName(field, name string) []*errdetails.BadRequest_FieldViolation {
+	if name == "" {
+		return []*errdetails.BadRequest_FieldViolation{{Field: field, Description: "is required"}}
+	}
+	var violations []*errdetails.BadRequest_FieldViolation
+	if n := utf8.RuneCountInString(name); n > maxNameLength {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fmt.Sprintf("must be at most %d characters, got %d", maxNameLength, n),
+		})
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && r != ' ' && r != '-' && r != '\'' && r != '.' {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       field,
+				Description: fmt.Sprintf("contains %q; only letters, spaces, hyphens, apostrophes and periods are allowed", r),
+			})
+			break
+		}
+	}
+	return violations
+}
+
+func validateHelloRequest(in *pb.HelloRequest) error {
+	violations := validateName("name", in.GetName())
+	if l := in.GetLanguage(); l != "" && !i18n.ValidTag(l) {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "language",
+			Description: fmt.Sprintf("%q is not a BCP 47 language tag such as \"en\" or \"pt-BR\"", l),
+		})
+	}
+	return invalidArgument(violations)
+}
+
+func validateHelloStreamRequest(in *pb.HelloStreamRequest) error {
+	violations := validateName("name", in.GetName())
+	if c := in.GetCount(); c < 0 || int(c) > *streamMaxCount {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "count",
+			Description: fmt.Sprintf("must be between 0 and %d, got %d", *streamMaxCount, c),
+		})
+	}
+	if in.GetIntervalMs() < 0 {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "interval_ms",
+			Description: fmt.Sprintf("must not be negative, got %d", in.GetIntervalMs()),
+		})
+	}
+	return invalidArgument(violations)
+}
+
+// invalidArgument returns a codes.InvalidArgument status carrying the
+// violations as a google.rpc.BadRequest detail, or nil if there are none.
+func invalidArgument(violations []*errdetails.BadRequest_FieldViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	st := status.New(codes.InvalidArgument, "invalid request")
+	ds, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return st.Err()
+	}
+	return ds.Err()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"grpc/grpcweb"
+	"grpc/interceptor"
+	"grpc/ratelimit"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+// webServer is the HTTP server fronting the gRPC server with -web. It
+// counts the requests its handler is serving, since http.Server stops
+// tracking h2c connections once they are hijacked from it.
+type webServer struct {
+	*http.Server
+	active atomic.Int64
+}
+
+// newWebServer fronts s with an HTTP server so browsers can call it with
+// gRPC-Web or Connect on the same port as native gRPC clients: HTTP/1.1 and
+// h2c in plaintext, HTTP/1.1 and h2 over TLS.
+func newWebServer(s *grpc.Server, tlsConfig *tls.Config) (*webServer, error) {
+	var origins []string
+	if *corsOrigins != "" {
+		origins = strings.Split(*corsOrigins, ",")
+	}
+	handler := grpcweb.NewHandler(s, grpcweb.Options{
+		AllowedOrigins: origins,
+		AllowedHeaders: []string{"Authorization", interceptor.RequestIDKey, acceptLanguageKey},
+		ExposedHeaders: []string{
+			interceptor.RequestIDKey,
+			"Trailer-" + interceptor.RequestIDKey,
+			"Trailer-" + ratelimit.RetryAfterKey,
+		},
+		MaxAge: 10 * time.Minute,
+	})
+	// grpc.MaxConcurrentStreams only applies to s's own transport.
+	h2 := &http2.Server{MaxConcurrentStreams: uint32(*maxConcurrentStreams)}
+	web := &webServer{}
+	handler = web.track(handler)
+	web.Server = &http.Server{Handler: handler, TLSConfig: tlsConfig, ReadHeaderTimeout: 10 * time.Second}
+	// Configuring the server with h2 in plaintext too lets Shutdown send
+	// GOAWAY on h2c connections.
+	if err := http2.ConfigureServer(web.Server, h2); err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		web.Handler = h2c.NewHandler(handler, h2)
+		// ConfigureServer filled in a TLS configuration; serveWebServer
+		// must still serve plaintext.
+		web.TLSConfig = nil
+	}
+	return web, nil
+}
+
+// track counts the requests h is serving. A gRPC call's trailers are
+// written after its handler and interceptors return, so only the HTTP
+// handler returning says a call is over.
+func (web *webServer) track(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		web.active.Add(1)
+		defer web.active.Add(-1)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Shutdown stops accepting calls and waits until none are being served or
+// ctx is done. HTTP/2 connections are sent GOAWAY, so their clients stop
+// starting calls on them.
+func (web *webServer) Shutdown(ctx context.Context) error {
+	if err := web.Server.Shutdown(ctx); err != nil {
+		return err
+	}
+	// Server.Shutdown only waits for HTTP/1.1 connections; poll the way it
+	// does for the hijacked h2c ones.
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for web.active.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// serveWebServer serves web on lis, over TLS if it has a TLS configuration.
+func serveWebServer(web *webServer, lis net.Listener) error {
+	if web.TLSConfig != nil {
+		// The certificate is already in TLSConfig.
+		return web.ServeTLS(lis, "", "")
+	}
+	return web.Serve(lis)
+}
@@ -0,0 +1,215 @@
+package greetertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	pb "grpc/helloworld"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultTimeout bounds a Case that does not set its own.
+const defaultTimeout = 5 * time.Second
+
+// Case is one call to make against a Greeter and the status it should end
+// with.
+type Case struct {
+	Name string
+	// Timeout is the deadline for the call; zero means five seconds.
+	Timeout time.Duration
+	// Call makes the RPC and checks the reply. A status error is compared
+	// with Code; any other error is a failed check.
+	Call func(ctx context.Context, c pb.GreeterClient) error
+	Code codes.Code
+}
+
+// Check runs tc against the harness client and describes how it went
+// wrong, or returns nil.
+func (h *Harness) Check(tc Case) error {
+	timeout := tc.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := tc.Call(ctx, h.Client)
+	st, isStatus := status.FromError(err)
+	switch {
+	case err != nil && !isStatus:
+		return fmt.Errorf("%s: %w", tc.Name, err)
+	case st.Code() != tc.Code:
+		return fmt.Errorf("%s: got %v (%q), want %v", tc.Name, st.Code(), st.Message(), tc.Code)
+	}
+	return nil
+}
+
+// Cases returns the checks every Greeter server built from greeter_server
+// should pass with its default flags and built-in message catalog.
+func Cases() []Case {
+	return []Case{
+		{
+			Name: "SayHello greets by name",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				r, err := c.SayHello(ctx, &pb.HelloRequest{Name: "Ana"})
+				if err != nil {
+					return err
+				}
+				return expectReply(r, "Hello Ana", "en")
+			},
+			Code: codes.OK,
+		},
+		{
+			Name: "SayHello falls back from pt-BR to pt",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				r, err := c.SayHello(ctx, &pb.HelloRequest{Name: "Ana", Language: "pt-BR"})
+				if err != nil {
+					return err
+				}
+				return expectReply(r, "Olá Ana", "pt")
+			},
+			Code: codes.OK,
+		},
+		{
+			Name: "SayHello rejects an empty name",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				_, err := c.SayHello(ctx, &pb.HelloRequest{})
+				return err
+			},
+			Code: codes.InvalidArgument,
+		},
+		{
+			Name: "SayHello rejects a malformed language",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				_, err := c.SayHello(ctx, &pb.HelloRequest{Name: "Ana", Language: "not a tag"})
+				return err
+			},
+			Code: codes.InvalidArgument,
+		},
+		{
+			Name: "SayHello with an expired deadline",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				ctx, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+				defer cancel()
+				_, err := c.SayHello(ctx, &pb.HelloRequest{Name: "Ana"})
+				return err
+			},
+			Code: codes.DeadlineExceeded,
+		},
+		{
+			Name: "SayHello after the caller cancels",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				ctx, cancel := context.WithCancel(ctx)
+				cancel()
+				_, err := c.SayHello(ctx, &pb.HelloRequest{Name: "Ana"})
+				return err
+			},
+			Code: codes.Canceled,
+		},
+		{
+			Name: "SayHelloAgain greets again",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				r, err := c.SayHelloAgain(ctx, &pb.HelloRequest{Name: "Bo"})
+				if err != nil {
+					return err
+				}
+				if !strings.HasPrefix(r.GetMessage(), "Hello again Bo") {
+					return fmt.Errorf("got message %q, want it to start with %q", r.GetMessage(), "Hello again Bo")
+				}
+				return nil
+			},
+			Code: codes.OK,
+		},
+		{
+			Name: "SayHelloAgain rejects a name over 64 characters",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				_, err := c.SayHelloAgain(ctx, &pb.HelloRequest{Name: strings.Repeat("a", 65)})
+				return err
+			},
+			Code: codes.InvalidArgument,
+		},
+		{
+			Name: "SayHelloStream sends the requested count",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				n, err := recvAll(ctx, c, &pb.HelloStreamRequest{Name: "Ana", Count: 3, IntervalMs: 1}, 0)
+				if err == nil && n != 3 {
+					return fmt.Errorf("got %d greetings, want 3", n)
+				}
+				return err
+			},
+			Code: codes.OK,
+		},
+		{
+			Name:    "SayHelloStream outlives its deadline",
+			Timeout: 200 * time.Millisecond,
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				_, err := recvAll(ctx, c, &pb.HelloStreamRequest{Name: "Ana", Count: 3, IntervalMs: 1000}, 0)
+				return err
+			},
+			Code: codes.DeadlineExceeded,
+		},
+		{
+			Name: "SayHelloStream cancelled after the first greeting",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				_, err := recvAll(ctx, c, &pb.HelloStreamRequest{Name: "Ana", Count: 3, IntervalMs: 1000}, 1)
+				return err
+			},
+			Code: codes.Canceled,
+		},
+		{
+			Name: "SayHelloStream rejects a negative count",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				_, err := recvAll(ctx, c, &pb.HelloStreamRequest{Name: "Ana", Count: -1}, 0)
+				return err
+			},
+			Code: codes.InvalidArgument,
+		},
+		{
+			Name: "ListGreetings rejects a malformed page token",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				_, err := c.ListGreetings(ctx, &pb.ListGreetingsRequest{PageToken: "!"})
+				return err
+			},
+			Code: codes.InvalidArgument,
+		},
+	}
+}
+
+// expectReply checks a unary reply's message and language.
+func expectReply(r *pb.HelloReply, message, language string) error {
+	if r.GetMessage() != message || r.GetLanguage() != language {
+		return fmt.Errorf("got %q in %q, want %q in %q", r.GetMessage(), r.GetLanguage(), message, language)
+	}
+	return nil
+}
+
+// recvAll reads the stream to the end and returns how many greetings
+// arrived. If cancelAfter is positive the call is cancelled once that many
+// have been received.
+func recvAll(ctx context.Context, c pb.GreeterClient, in *pb.HelloStreamRequest, cancelAfter int) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.SayHelloStream(ctx, in)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return n, nil
+			}
+			return n, err
+		}
+		n++
+		if n == cancelAfter {
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package greetertest
+
+import (
+	"context"
+	"strings"
+
+	pb "grpc/helloworld"
+	"grpc/zstd"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// SizeLimitCases returns the checks for a server started with
+// grpc.MaxRecvMsgSize(limit): requests over the limit fail with
+// RESOURCE_EXHAUSTED before reaching the handler, compressed or not, while
+// ones under it get as far as name validation. limit must be well over the
+// 64 characters validation allows.
+//
+//	h, err := greetertest.Start(impl, greetertest.WithServerOptions(grpc.MaxRecvMsgSize(1024)))
+//	...
+//	for _, tc := range greetertest.SizeLimitCases(1024) {
+func SizeLimitCases(limit int) []Case {
+	over := strings.Repeat("a", limit+1)
+	under := strings.Repeat("a", limit/2)
+	sayHello := func(name string, opts ...grpc.CallOption) func(context.Context, pb.GreeterClient) error {
+		return func(ctx context.Context, c pb.GreeterClient) error {
+			_, err := c.SayHello(ctx, &pb.HelloRequest{Name: name}, opts...)
+			return err
+		}
+	}
+	return []Case{
+		{
+			Name: "SayHello with a name over the server's receive limit",
+			Call: sayHello(over),
+			Code: codes.ResourceExhausted,
+		},
+		{
+			// The limit applies to the message once decompressed, so a
+			// name that compresses well doesn't get around it.
+			Name: "SayHello with a gzip compressed name over the receive limit",
+			Call: sayHello(over, grpc.UseCompressor(gzip.Name)),
+			Code: codes.ResourceExhausted,
+		},
+		{
+			Name: "SayHello with a zstd compressed name over the receive limit",
+			Call: sayHello(over, grpc.UseCompressor(zstd.Name)),
+			Code: codes.ResourceExhausted,
+		},
+		{
+			Name: "SayHello with a long name under the receive limit",
+			Call: sayHello(under),
+			Code: codes.InvalidArgument,
+		},
+		{
+			Name: "SayHello compressed with zstd",
+			Call: func(ctx context.Context, c pb.GreeterClient) error {
+				r, err := c.SayHello(ctx, &pb.HelloRequest{Name: "Ana"}, grpc.UseCompressor(zstd.Name))
+				if err != nil {
+					return err
+				}
+				return expectReply(r, "Hello Ana", "en")
+			},
+			Code: codes.OK,
+		},
+		{
+			// The client refuses to send it at all.
+			Name: "SayHello with a name over the client's send limit",
+			Call: sayHello(over, grpc.MaxCallSendMsgSize(limit)),
+			Code: codes.ResourceExhausted,
+		},
+		{
+			Name: "SayHello with a reply over the client's receive limit",
+			Call: sayHello("Ana", grpc.MaxCallRecvMsgSize(8)),
+			Code: codes.ResourceExhausted,
+		},
+	}
+}
@@ -0,0 +1,269 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"grpc/gateway"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	connectJSONContentType  = "application/json"
+	connectProtoContentType = "application/proto"
+
+	// maxConnectRequest matches the default limit grpc.Server puts on a
+	// received message.
+	maxConnectRequest = 4 << 20
+)
+
+func isConnectContentType(ct string) bool {
+	ct, _, _ = strings.Cut(ct, ";")
+	return ct == connectJSONContentType || ct == connectProtoContentType
+}
+
+// serveConnect serves a Connect unary call. The body is a bare message,
+// JSON or binary, so it is wrapped in a gRPC frame for the native call and
+// the reply unwrapped again; the gRPC status becomes a Connect error.
+func (h *handler) serveConnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Connect unary calls must be POST", http.StatusMethodNotAllowed)
+		return
+	}
+	ct, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";")
+	isJSON := ct == connectJSONContentType
+
+	method, err := lookupMethod(r.URL.Path)
+	if err != nil {
+		writeConnectError(w, status.New(codes.Unimplemented, err.Error()))
+		return
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		writeConnectError(w, status.Newf(codes.Unimplemented, "%s is a streaming method; call it with gRPC-Web", r.URL.Path))
+		return
+	}
+	if enc := r.Header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+		writeConnectError(w, status.Newf(codes.Unimplemented, "unsupported content-encoding %q", enc))
+		return
+	}
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxConnectRequest))
+	if err != nil {
+		writeConnectError(w, status.Newf(codes.InvalidArgument, "read request: %v", err))
+		return
+	}
+	if isJSON {
+		if payload, err = jsonToProto(method.Input(), payload); err != nil {
+			writeConnectError(w, status.Newf(codes.InvalidArgument, "decode request: %v", err))
+			return
+		}
+	}
+
+	frame := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	nr := nativeRequest(r, bytes.NewReader(append(frame, payload...)), grpcContentType+"+proto")
+	for _, k := range []string{"Connect-Protocol-Version", "Connect-Timeout-Ms", "Content-Encoding", "Accept-Encoding", "Grpc-Accept-Encoding"} {
+		nr.Header.Del(k)
+	}
+	if ms := r.Header.Get("Connect-Timeout-Ms"); ms != "" {
+		if _, err := strconv.ParseUint(ms, 10, 63); err != nil {
+			writeConnectError(w, status.Newf(codes.InvalidArgument, "invalid Connect-Timeout-Ms %q", ms))
+			return
+		}
+		nr.Header.Set("Grpc-Timeout", ms+"m")
+	}
+
+	rec := &recorder{header: make(http.Header)}
+	h.server.ServeHTTP(rec, nr)
+
+	trailers := trailersOf(rec.header)
+	out := w.Header()
+	for k, v := range headersOf(rec.sent) {
+		out[k] = v
+	}
+	for k, v := range headersOf(trailers) {
+		out["Trailer-"+k] = v
+	}
+	st := statusOf(trailers, rec.body.Bytes())
+	if st.Code() != codes.OK {
+		writeConnectError(w, st)
+		return
+	}
+	reply, err := firstMessage(rec.body.Bytes())
+	if err == nil && isJSON {
+		reply, err = protoToJSON(method.Output(), reply)
+	}
+	if err != nil {
+		writeConnectError(w, status.Newf(codes.Internal, "encode response: %v", err))
+		return
+	}
+	out.Set("Content-Type", ct)
+	w.Write(reply)
+}
+
+// lookupMethod finds the method for a "/package.Service/Method" path among
+// the descriptors linked into the binary.
+func lookupMethod(path string) (protoreflect.MethodDescriptor, error) {
+	service, name, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("malformed method path %q", path)
+	}
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("unknown service %s", service)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("unknown service %s", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("unknown method %s for service %s", name, service)
+	}
+	return md, nil
+}
+
+func jsonToProto(desc protoreflect.MessageDescriptor, b []byte) ([]byte, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName())
+	if err != nil {
+		return nil, err
+	}
+	m := mt.New().Interface()
+	if err := protojson.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(m)
+}
+
+func protoToJSON(desc protoreflect.MessageDescriptor, b []byte) ([]byte, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName())
+	if err != nil {
+		return nil, err
+	}
+	m := mt.New().Interface()
+	if err := proto.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(m)
+}
+
+// firstMessage returns the payload of the first frame in a gRPC body.
+func firstMessage(body []byte) ([]byte, error) {
+	if len(body) < 5 {
+		return nil, fmt.Errorf("short gRPC frame")
+	}
+	if body[0] != 0 {
+		return nil, fmt.Errorf("compressed gRPC frame")
+	}
+	n := binary.BigEndian.Uint32(body[1:5])
+	if uint64(len(body)-5) < uint64(n) {
+		return nil, fmt.Errorf("truncated gRPC frame")
+	}
+	return body[5 : 5+n], nil
+}
+
+// statusOf reads the call's status from its trailers. A response without
+// one is an HTTP level failure, whose body says what went wrong.
+func statusOf(trailers http.Header, body []byte) *status.Status {
+	if b := trailers.Get("Grpc-Status-Details-Bin"); b != "" {
+		raw, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(b, "="))
+		p := &spb.Status{}
+		if err == nil && proto.Unmarshal(raw, p) == nil {
+			return status.FromProto(p)
+		}
+	}
+	code, err := strconv.Atoi(trailers.Get("Grpc-Status"))
+	if err != nil {
+		return status.New(codes.Internal, strings.TrimSpace(string(body)))
+	}
+	msg := trailers.Get("Grpc-Message")
+	if m, err := url.PathUnescape(msg); err == nil {
+		msg = m
+	}
+	return status.New(codes.Code(code), msg)
+}
+
+// connectError is the JSON body of a failed Connect call.
+type connectError struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message,omitempty"`
+	Details []connectDetail `json:"details,omitempty"`
+}
+
+type connectDetail struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func writeConnectError(w http.ResponseWriter, st *status.Status) {
+	ce := connectError{Code: connectCode(st.Code()), Message: st.Message()}
+	for _, d := range st.Proto().GetDetails() {
+		ce.Details = append(ce.Details, connectDetail{
+			Type:  strings.TrimPrefix(d.GetTypeUrl(), "type.googleapis.com/"),
+			Value: base64.RawStdEncoding.EncodeToString(d.GetValue()),
+		})
+	}
+	w.Header().Set("Content-Type", connectJSONContentType)
+	// Connect maps codes to HTTP statuses the same way as the gateway.
+	w.WriteHeader(gateway.HTTPStatusFromCode(st.Code()))
+	json.NewEncoder(w).Encode(ce)
+}
+
+// connectCode spells a code the way Connect does: "InvalidArgument" is
+// "invalid_argument".
+func connectCode(c codes.Code) string {
+	var b strings.Builder
+	for i, r := range c.String() {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// recorder buffers a whole native response for the Connect translation,
+// remembering the headers as they were when the call committed them.
+type recorder struct {
+	header http.Header
+	sent   http.Header
+	body   bytes.Buffer
+}
+
+func (rec *recorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *recorder) WriteHeader(int) {
+	if rec.sent == nil {
+		rec.sent = rec.header.Clone()
+	}
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
+
+func (rec *recorder) Flush() {
+	rec.WriteHeader(http.StatusOK)
+}
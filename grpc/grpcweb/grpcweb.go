@@ -0,0 +1,151 @@
+// Package grpcweb lets browsers call a grpc.Server. The handler it returns
+// serves three protocols on one HTTP endpoint, over HTTP/1.1 or HTTP/2:
+//
+//   - native gRPC, handed straight to grpc.Server.ServeHTTP;
+//   - gRPC-Web, binary or base64 text, unary and server streaming;
+//   - Connect unary calls with JSON or binary protobuf bodies.
+//
+// gRPC-Web and Connect requests are rewritten into native gRPC requests
+// and served by the same grpc.Server, so they pass through its
+// interceptors like any other call. Connect streaming calls are not
+// supported; browsers can use gRPC-Web for those.
+package grpcweb
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/cors"
+	"google.golang.org/grpc"
+)
+
+const (
+	grpcContentType        = "application/grpc"
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+)
+
+// protocolRequestHeaders are the request headers gRPC-Web and Connect
+// clients send, which CORS must allow.
+var protocolRequestHeaders = []string{
+	"Content-Type",
+	"X-Grpc-Web",
+	"X-User-Agent",
+	"Grpc-Timeout",
+	"Connect-Protocol-Version",
+	"Connect-Timeout-Ms",
+}
+
+// protocolResponseHeaders are the response headers clients read to learn
+// how a call ended, which CORS must expose.
+var protocolResponseHeaders = []string{
+	"Grpc-Status",
+	"Grpc-Message",
+	"Grpc-Status-Details-Bin",
+}
+
+// Options configures the handler returned by NewHandler.
+type Options struct {
+	// AllowedOrigins lists the origins pages may call from, "*" for any.
+	// When empty no CORS headers are sent, so only same-origin pages can
+	// make calls.
+	AllowedOrigins []string
+	// AllowedHeaders are request headers, such as Authorization, that
+	// pages may send on top of the ones the protocols need.
+	AllowedHeaders []string
+	// ExposedHeaders are response headers pages may read on top of the
+	// ones the protocols need. Connect sends trailers as headers prefixed
+	// with "Trailer-", so list those too.
+	ExposedHeaders []string
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+type handler struct {
+	server *grpc.Server
+}
+
+// NewHandler returns an http.Handler serving s to native gRPC, gRPC-Web and
+// Connect clients. To share a port with HTTP/1.1 clients, serve it from an
+// http.Server instead of calling s.Serve; grpc.Server options that only
+// apply to its own transport, such as MaxConcurrentStreams, then have to be
+// set on the http.Server.
+func NewHandler(s *grpc.Server, opts Options) http.Handler {
+	h := &handler{server: s}
+	if len(opts.AllowedOrigins) == 0 {
+		return h
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins: opts.AllowedOrigins,
+		AllowedMethods: []string{http.MethodPost},
+		AllowedHeaders: append(slices.Clone(protocolRequestHeaders), opts.AllowedHeaders...),
+		ExposedHeaders: append(slices.Clone(protocolResponseHeaders), opts.ExposedHeaders...),
+		MaxAge:         int(opts.MaxAge.Seconds()),
+	}).Handler(h)
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ct := r.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(ct, grpcWebContentType):
+		h.serveGRPCWeb(w, r)
+	case strings.HasPrefix(ct, grpcContentType):
+		h.server.ServeHTTP(w, r)
+	case isConnectContentType(ct):
+		h.serveConnect(w, r)
+	default:
+		http.Error(w, "unsupported content type "+ct, http.StatusUnsupportedMediaType)
+	}
+}
+
+// nativeRequest returns r rewritten as an HTTP/2 gRPC request reading its
+// body from body. grpc.Server.ServeHTTP only looks at the protocol version
+// to reject HTTP/1.1, which the rewritten request no longer needs.
+func nativeRequest(r *http.Request, body io.Reader, contentType string) *http.Request {
+	nr := r.Clone(r.Context())
+	nr.ProtoMajor, nr.ProtoMinor, nr.Proto = 2, 0, "HTTP/2.0"
+	nr.Header.Set("Content-Type", contentType)
+	nr.Header.Del("Content-Length")
+	nr.ContentLength = -1
+	nr.Body = io.NopCloser(body)
+	return nr
+}
+
+// trailersOf returns the trailers grpc.Server.ServeHTTP left in h once
+// the call is over: the values of the names it declared in the Trailer
+// header, and the ones it set with the http.TrailerPrefix convention.
+func trailersOf(h http.Header) http.Header {
+	t := make(http.Header)
+	for _, names := range h.Values("Trailer") {
+		for _, name := range strings.Split(names, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if v := h.Values(name); len(v) > 0 {
+				t[name] = v
+			}
+		}
+	}
+	for k, v := range h {
+		if name, ok := strings.CutPrefix(k, http.TrailerPrefix); ok {
+			t[http.CanonicalHeaderKey(name)] = append(t[http.CanonicalHeaderKey(name)], v...)
+		}
+	}
+	return t
+}
+
+// headersOf returns the response headers in h that are not framing or
+// status, which both translations pass on as they are.
+func headersOf(h http.Header) http.Header {
+	out := make(http.Header)
+	for k, v := range h {
+		switch {
+		case k == "Trailer", k == "Content-Type", k == "Content-Length", len(v) == 0:
+		case strings.HasPrefix(k, http.TrailerPrefix), strings.HasPrefix(k, "Grpc-"):
+		default:
+			out[k] = slices.Clone(v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,359 @@
+package grpcweb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grpc/greetertest"
+	pb "grpc/helloworld"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// greeter fails calls without a name with a BadRequest detail, and sets a
+// header and a trailer on every call so their translation can be checked.
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	grpc.SetHeader(ctx, metadata.Pairs("x-greeter", "test"))
+	grpc.SetTrailer(ctx, metadata.Pairs("x-demo", "done"))
+	if in.GetName() == "" {
+		st, _ := status.New(codes.InvalidArgument, "invalid request").WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "name", Description: "is required"}},
+		})
+		return nil, st.Err()
+	}
+	return &pb.HelloReply{Message: "Hello " + in.GetName()}, nil
+}
+
+func (greeter) SayHelloStream(in *pb.HelloStreamRequest, stream pb.Greeter_SayHelloStreamServer) error {
+	for i := 1; i <= int(in.GetCount()); i++ {
+		if err := stream.Send(&pb.HelloReply{Message: fmt.Sprintf("Hello %s #%d", in.GetName(), i)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startWeb serves a greeter through NewHandler over HTTP/1.1, the way a
+// browser reaches it.
+func startWeb(t *testing.T) *httptest.Server {
+	t.Helper()
+	h, err := greetertest.Start(greeter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	srv := httptest.NewServer(NewHandler(h.Server, Options{}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, url, contentType string, body []byte) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func frame(t *testing.T, m proto.Message) []byte {
+	t.Helper()
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := make([]byte, 5, 5+len(b))
+	binary.BigEndian.PutUint32(f[1:], uint32(len(b)))
+	return append(f, b...)
+}
+
+// webResponse is a gRPC-Web response body split into its message frames
+// and the trailers from its final frame.
+type webResponse struct {
+	messages [][]byte
+	trailers http.Header
+}
+
+func parseWebResponse(t *testing.T, body []byte) webResponse {
+	t.Helper()
+	var r webResponse
+	for len(body) > 0 {
+		if len(body) < 5 {
+			t.Fatalf("short frame header %q", body)
+		}
+		flag, n := body[0], binary.BigEndian.Uint32(body[1:5])
+		if uint64(len(body)-5) < uint64(n) {
+			t.Fatalf("frame of %d bytes truncated to %d", n, len(body)-5)
+		}
+		payload := body[5 : 5+n]
+		body = body[5+n:]
+		if flag&trailerFrameFlag == 0 {
+			r.messages = append(r.messages, payload)
+			continue
+		}
+		if r.trailers != nil {
+			t.Fatal("more than one trailer frame")
+		}
+		r.trailers = make(http.Header)
+		for _, line := range strings.Split(strings.TrimSpace(string(payload)), "\r\n") {
+			k, v, ok := strings.Cut(line, ": ")
+			if !ok {
+				t.Fatalf("malformed trailer line %q", line)
+			}
+			r.trailers.Add(k, v)
+		}
+		if len(body) > 0 {
+			t.Fatalf("%d bytes after the trailer frame", len(body))
+		}
+	}
+	if r.trailers == nil {
+		t.Fatal("response has no trailer frame")
+	}
+	return r
+}
+
+// decodeText decodes a grpc-web-text body. Each flush is encoded on its
+// own, so padding can appear in the middle and every padded chunk has to
+// be decoded separately.
+func decodeText(t *testing.T, body []byte) []byte {
+	t.Helper()
+	var out []byte
+	for len(body) > 0 {
+		end := bytes.IndexByte(body, '=')
+		if end < 0 {
+			end = len(body)
+		}
+		for end < len(body) && body[end] == '=' {
+			end++
+		}
+		b, err := base64.StdEncoding.DecodeString(string(body[:end]))
+		if err != nil {
+			t.Fatalf("decode %q: %v", body[:end], err)
+		}
+		out = append(out, b...)
+		body = body[end:]
+	}
+	return out
+}
+
+func TestGRPCWeb(t *testing.T) {
+	srv := startWeb(t)
+	tests := []struct {
+		name string
+		text bool
+	}{
+		{"binary", false},
+		{"text", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType := grpcWebContentType + "+proto"
+			if tc.text {
+				contentType = grpcWebTextContentType + "+proto"
+			}
+			call := func(method string, req proto.Message) (*http.Response, webResponse) {
+				body := frame(t, req)
+				if tc.text {
+					body = []byte(base64.StdEncoding.EncodeToString(body))
+				}
+				resp := post(t, srv.URL+"/helloworld.Greeter/"+method, contentType, body)
+				raw, err := io.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("%s: HTTP %d: %s", method, resp.StatusCode, raw)
+				}
+				if got := resp.Header.Get("Content-Type"); got != contentType {
+					t.Errorf("%s: Content-Type is %q, want %q", method, got, contentType)
+				}
+				if tc.text {
+					raw = decodeText(t, raw)
+				}
+				return resp, parseWebResponse(t, raw)
+			}
+
+			t.Run("unary", func(t *testing.T) {
+				resp, r := call("SayHello", &pb.HelloRequest{Name: "Ana"})
+				if len(r.messages) != 1 {
+					t.Fatalf("got %d messages, want 1", len(r.messages))
+				}
+				var reply pb.HelloReply
+				if err := proto.Unmarshal(r.messages[0], &reply); err != nil {
+					t.Fatal(err)
+				}
+				if reply.GetMessage() != "Hello Ana" {
+					t.Errorf("reply is %q, want %q", reply.GetMessage(), "Hello Ana")
+				}
+				if got := r.trailers.Get("grpc-status"); got != "0" {
+					t.Errorf("grpc-status trailer is %q, want 0", got)
+				}
+				if got := r.trailers.Get("x-demo"); got != "done" {
+					t.Errorf("x-demo trailer is %q, want done", got)
+				}
+				if got := resp.Header.Get("X-Greeter"); got != "test" {
+					t.Errorf("X-Greeter header is %q, want test", got)
+				}
+			})
+
+			t.Run("server streaming", func(t *testing.T) {
+				_, r := call("SayHelloStream", &pb.HelloStreamRequest{Name: "Ana", Count: 3})
+				if len(r.messages) != 3 {
+					t.Fatalf("got %d messages, want 3", len(r.messages))
+				}
+				var reply pb.HelloReply
+				if err := proto.Unmarshal(r.messages[2], &reply); err != nil {
+					t.Fatal(err)
+				}
+				if reply.GetMessage() != "Hello Ana #3" {
+					t.Errorf("last reply is %q, want %q", reply.GetMessage(), "Hello Ana #3")
+				}
+				if got := r.trailers.Get("grpc-status"); got != "0" {
+					t.Errorf("grpc-status trailer is %q, want 0", got)
+				}
+			})
+
+			t.Run("error", func(t *testing.T) {
+				_, r := call("SayHello", &pb.HelloRequest{})
+				if len(r.messages) != 0 {
+					t.Errorf("got %d messages with an error, want none", len(r.messages))
+				}
+				if got := r.trailers.Get("grpc-status"); got != fmt.Sprint(int(codes.InvalidArgument)) {
+					t.Errorf("grpc-status trailer is %q, want %d", got, codes.InvalidArgument)
+				}
+				if got := r.trailers.Get("grpc-message"); got != "invalid request" {
+					t.Errorf("grpc-message trailer is %q, want %q", got, "invalid request")
+				}
+				if r.trailers.Get("grpc-status-details-bin") == "" {
+					t.Error("grpc-status-details-bin trailer is missing")
+				}
+				if got := r.trailers.Get("x-demo"); got != "done" {
+					t.Errorf("x-demo trailer is %q, want done", got)
+				}
+			})
+		})
+	}
+}
+
+func TestConnectJSON(t *testing.T) {
+	srv := startWeb(t)
+	url := srv.URL + "/helloworld.Greeter/SayHello"
+
+	t.Run("success", func(t *testing.T) {
+		resp := post(t, url, connectJSONContentType, []byte(`{"name": "Ana"}`))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("HTTP status is %d, want 200", resp.StatusCode)
+		}
+		var reply struct{ Message string }
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply.Message != "Hello Ana" {
+			t.Errorf("message is %q, want %q", reply.Message, "Hello Ana")
+		}
+		if got := resp.Header.Get("Trailer-X-Demo"); got != "done" {
+			t.Errorf("Trailer-X-Demo header is %q, want done", got)
+		}
+		if got := resp.Header.Get("X-Greeter"); got != "test" {
+			t.Errorf("X-Greeter header is %q, want test", got)
+		}
+	})
+
+	t.Run("error with details", func(t *testing.T) {
+		resp := post(t, url, connectJSONContentType, []byte(`{}`))
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("HTTP status is %d, want 400", resp.StatusCode)
+		}
+		var ce connectError
+		if err := json.NewDecoder(resp.Body).Decode(&ce); err != nil {
+			t.Fatal(err)
+		}
+		if ce.Code != "invalid_argument" || ce.Message != "invalid request" {
+			t.Errorf("error is %q %q, want invalid_argument %q", ce.Code, ce.Message, "invalid request")
+		}
+		if len(ce.Details) != 1 || ce.Details[0].Type != "google.rpc.BadRequest" {
+			t.Fatalf("details are %+v, want one google.rpc.BadRequest", ce.Details)
+		}
+		raw, err := base64.RawStdEncoding.DecodeString(ce.Details[0].Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var br errdetails.BadRequest
+		if err := proto.Unmarshal(raw, &br); err != nil {
+			t.Fatal(err)
+		}
+		if v := br.GetFieldViolations(); len(v) != 1 || v[0].GetField() != "name" {
+			t.Errorf("field violations are %v, want one for name", v)
+		}
+		if got := resp.Header.Get("Trailer-X-Demo"); got != "done" {
+			t.Errorf("Trailer-X-Demo header is %q, want done", got)
+		}
+	})
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		resp := post(t, url, connectJSONContentType, []byte(`{"name":`))
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("HTTP status is %d, want 400", resp.StatusCode)
+		}
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		resp := post(t, srv.URL+"/helloworld.Greeter/SayGoodbye", connectJSONContentType, []byte(`{}`))
+		if resp.StatusCode != http.StatusNotImplemented {
+			t.Errorf("HTTP status is %d, want 501", resp.StatusCode)
+		}
+	})
+
+	t.Run("streaming method", func(t *testing.T) {
+		resp := post(t, srv.URL+"/helloworld.Greeter/SayHelloStream", connectJSONContentType, []byte(`{"name": "Ana"}`))
+		var ce connectError
+		if err := json.NewDecoder(resp.Body).Decode(&ce); err != nil {
+			t.Fatal(err)
+		}
+		if ce.Code != "unimplemented" {
+			t.Errorf("error code is %q, want unimplemented", ce.Code)
+		}
+	})
+}
+
+func TestConnectProto(t *testing.T) {
+	srv := startWeb(t)
+	body, err := proto.Marshal(&pb.HelloRequest{Name: "Ana"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := post(t, srv.URL+"/helloworld.Greeter/SayHello", connectProtoContentType, body)
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("HTTP %d: %s", resp.StatusCode, raw)
+	}
+	var reply pb.HelloReply
+	if err := proto.Unmarshal(raw, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.GetMessage() != "Hello Ana" {
+		t.Errorf("reply is %q, want %q", reply.GetMessage(), "Hello Ana")
+	}
+}
@@ -0,0 +1,109 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// trailerFrameFlag marks the gRPC-Web frame that carries the trailers at
+// the end of the body, since browsers can't read HTTP trailers.
+const trailerFrameFlag = 0x80
+
+// serveGRPCWeb serves a gRPC-Web call. The request body is already in gRPC
+// framing, so only its encoding and content type change; the response is
+// streamed back as it is written, ending with a trailer frame.
+func (h *handler) serveGRPCWeb(w http.ResponseWriter, r *http.Request) {
+	ct := r.Header.Get("Content-Type")
+	text := strings.HasPrefix(ct, grpcWebTextContentType)
+	subtype := strings.TrimPrefix(ct, grpcWebContentType)
+	respType := grpcWebContentType + "+proto"
+	var body io.Reader = r.Body
+	if text {
+		subtype = strings.TrimPrefix(ct, grpcWebTextContentType)
+		respType = grpcWebTextContentType + "+proto"
+		body = base64.NewDecoder(base64.StdEncoding, r.Body)
+	}
+
+	nr := nativeRequest(r, body, grpcContentType+subtype)
+	// Browsers can't inflate gRPC message compression.
+	nr.Header.Del("Grpc-Accept-Encoding")
+	ww := &webWriter{w: w, header: make(http.Header), text: text, contentType: respType}
+	h.server.ServeHTTP(ww, nr)
+	ww.finish()
+}
+
+// webWriter turns what grpc.Server.ServeHTTP writes into a gRPC-Web
+// response: headers go out when the call first writes, message frames as
+// they come, and the trailers as a final frame.
+type webWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	text        bool
+	contentType string
+	wroteHeader bool
+	// pending holds bytes not yet base64 encoded in text mode. Each flush
+	// encodes whole frames, so clients can decode every chunk on its own.
+	pending bytes.Buffer
+}
+
+func (ww *webWriter) Header() http.Header {
+	return ww.header
+}
+
+func (ww *webWriter) WriteHeader(code int) {
+	if ww.wroteHeader {
+		return
+	}
+	ww.wroteHeader = true
+	h := ww.w.Header()
+	for k, v := range headersOf(ww.header) {
+		h[k] = v
+	}
+	h.Set("Content-Type", ww.contentType)
+	ww.w.WriteHeader(code)
+}
+
+func (ww *webWriter) Write(b []byte) (int, error) {
+	ww.WriteHeader(http.StatusOK)
+	if ww.text {
+		return ww.pending.Write(b)
+	}
+	return ww.w.Write(b)
+}
+
+func (ww *webWriter) Flush() {
+	ww.WriteHeader(http.StatusOK)
+	if ww.text && ww.pending.Len() > 0 {
+		ww.w.Write([]byte(base64.StdEncoding.EncodeToString(ww.pending.Bytes())))
+		ww.pending.Reset()
+	}
+	if f, ok := ww.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the trailer frame once the call is over.
+func (ww *webWriter) finish() {
+	trailers := trailersOf(ww.header)
+	names := make([]string, 0, len(trailers))
+	for k := range trailers {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	var block bytes.Buffer
+	for _, k := range names {
+		for _, v := range trailers[k] {
+			block.WriteString(strings.ToLower(k) + ": " + v + "\r\n")
+		}
+	}
+	frame := make([]byte, 5, 5+block.Len())
+	frame[0] = trailerFrameFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(block.Len()))
+	ww.Write(append(frame, block.Bytes()...))
+	ww.Flush()
+}
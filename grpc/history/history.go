@@ -0,0 +1,38 @@
+// Package history records the greetings a server sends so they can be listed
+// later. Store has an in-memory implementation for demos and a SQLite one
+// that survives restarts.
+package history
+
+import (
+	"context"
+	"time"
+)
+
+// Greeting is one recorded greeting. ID is assigned by the store and grows
+// with every Add, which is what pagination keys on.
+type Greeting struct {
+	ID        int64
+	Name      string
+	Message   string
+	Peer      string
+	CreatedAt time.Time
+}
+
+// Query selects a page of greetings in ID order.
+type Query struct {
+	// Name, when set, only matches greetings for that name.
+	Name string
+	// AfterID skips greetings up to and including this ID.
+	AfterID int64
+	// Limit is the most greetings to return.
+	Limit int
+}
+
+// Store persists greetings.
+type Store interface {
+	Add(ctx context.Context, g Greeting) error
+	List(ctx context.Context, q Query) ([]Greeting, error)
+	// Count returns how many greetings have been recorded for name.
+	Count(ctx context.Context, name string) (int, error)
+	Close() error
+}
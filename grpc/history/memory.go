@@ -0,0 +1,73 @@
+package history
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps the most recent greetings in memory; they are gone on
+// restart. Once it holds its capacity, each Add drops the oldest greeting,
+// and Count only counts the greetings still held.
+type MemoryStore struct {
+	capacity int
+
+	mu        sync.RWMutex
+	greetings []Greeting
+	lastID    int64
+	// counts is the number of greetings held per name, so Count doesn't
+	// have to scan them all.
+	counts map[string]int
+}
+
+// NewMemoryStore returns an empty MemoryStore that holds up to capacity
+// greetings, which must be positive.
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{capacity: capacity, counts: make(map[string]int)}
+}
+
+func (s *MemoryStore) Add(_ context.Context, g Greeting) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	g.ID = s.lastID
+	s.greetings = append(s.greetings, g)
+	s.counts[g.Name]++
+	if len(s.greetings) > s.capacity {
+		oldest := s.greetings[0]
+		// Clear the slot so the dropped strings can be collected before
+		// append next copies the slice.
+		s.greetings[0] = Greeting{}
+		s.greetings = s.greetings[1:]
+		if s.counts[oldest.Name]--; s.counts[oldest.Name] == 0 {
+			delete(s.counts, oldest.Name)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) List(_ context.Context, q Query) ([]Greeting, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	start := sort.Search(len(s.greetings), func(i int) bool { return s.greetings[i].ID > q.AfterID })
+	var page []Greeting
+	for _, g := range s.greetings[start:] {
+		if len(page) == q.Limit {
+			break
+		}
+		if q.Name == "" || g.Name == q.Name {
+			page = append(page, g)
+		}
+	}
+	return page, nil
+}
+
+func (s *MemoryStore) Count(_ context.Context, name string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.counts[name], nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,47 @@
+package history
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryStoreDropsOldest(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(3)
+	for _, name := range []string{"Ana", "Bo", "Ana", "Cy", "Ana"} {
+		if err := s.Add(ctx, Greeting{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := s.List(ctx, Query{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, g := range page {
+		ids = append(ids, g.ID)
+	}
+	if len(ids) != 3 || ids[0] != 3 || ids[2] != 5 {
+		t.Errorf("List returned IDs %v, want the newest three, [3 4 5]", ids)
+	}
+
+	// A page token from before the eviction carries on from the oldest
+	// greeting still held.
+	page, err = s.List(ctx, Query{AfterID: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].ID != 3 {
+		t.Errorf("List after ID 1 returned %v, want ID 3", page)
+	}
+
+	for name, want := range map[string]int{"Ana": 2, "Bo": 0, "Cy": 1} {
+		if n, _ := s.Count(ctx, name); n != want {
+			t.Errorf("Count(%s) = %d, want %d", name, n, want)
+		}
+	}
+	if _, ok := s.counts["Bo"]; ok {
+		t.Error("count for Bo kept after all its greetings were dropped")
+	}
+}
@@ -0,0 +1,79 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS greetings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    message TEXT NOT NULL,
+    peer TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_greetings_name ON greetings(name, id);
+`
+
+// SQLiteStore keeps greetings in a SQLite database file.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens, creating if needed, the database at path.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Add(ctx context.Context, g Greeting) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO greetings (name, message, peer, created_at) VALUES (?, ?, ?, ?)",
+		g.Name, g.Message, g.Peer, g.CreatedAt.UTC())
+	return err
+}
+
+func (s *SQLiteStore) List(ctx context.Context, q Query) ([]Greeting, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, message, peer, created_at FROM greetings
+		WHERE id > ? AND (? = '' OR name = ?)
+		ORDER BY id
+		LIMIT ?`,
+		q.AfterID, q.Name, q.Name, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var page []Greeting
+	for rows.Next() {
+		var g Greeting
+		if err := rows.Scan(&g.ID, &g.Name, &g.Message, &g.Peer, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		page = append(page, g)
+	}
+	return page, rows.Err()
+}
+
+func (s *SQLiteStore) Count(ctx context.Context, name string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM greetings WHERE name = ?", name).Scan(&n)
+	return n, err
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,155 @@
+// Package i18n translates the server's messages. A Catalog holds message
+// templates per language; Format walks the caller's preferred languages,
+// each followed by its fallback chain, until one has the message, and picks
+// the template for the count using that language's plural rules.
+package i18n
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed messages.json
+var builtinMessages []byte
+
+// Message holds one template per plural category. Templates refer to their
+// arguments as {name}; {count} is always available.
+type Message map[string]string
+
+// catalogFile is the JSON layout of a catalog, see messages.json.
+type catalogFile struct {
+	Default   string                        `json:"default"`
+	Fallbacks map[string][]string           `json:"fallbacks"`
+	Messages  map[string]map[string]Message `json:"messages"`
+}
+
+// Catalog is a set of translated messages. It is safe for concurrent use.
+type Catalog struct {
+	def       string
+	tags      map[string]string // normalized tag to the tag as written
+	fallbacks map[string][]string
+	messages  map[string]map[string]Message
+}
+
+// Parse reads a catalog in the layout of messages.json. Every message must
+// have an "other" template, and the default language must be present.
+func Parse(data []byte) (*Catalog, error) {
+	var f catalogFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse catalog: %w", err)
+	}
+	c := &Catalog{
+		def:       normalize(f.Default),
+		tags:      make(map[string]string),
+		fallbacks: make(map[string][]string),
+		messages:  make(map[string]map[string]Message),
+	}
+	for tag, msgs := range f.Messages {
+		if !ValidTag(tag) {
+			return nil, fmt.Errorf("catalog: invalid language tag %q", tag)
+		}
+		for key, m := range msgs {
+			if _, ok := m[Other]; !ok {
+				return nil, fmt.Errorf("catalog: %s message %q has no %q form", tag, key, Other)
+			}
+		}
+		c.tags[normalize(tag)] = tag
+		c.messages[normalize(tag)] = msgs
+	}
+	for tag, chain := range f.Fallbacks {
+		for _, fb := range chain {
+			c.fallbacks[normalize(tag)] = append(c.fallbacks[normalize(tag)], normalize(fb))
+		}
+	}
+	if _, ok := c.messages[c.def]; !ok {
+		return nil, fmt.Errorf("catalog: default language %q has no messages", f.Default)
+	}
+	return c, nil
+}
+
+var builtin = sync.OnceValue(func() *Catalog {
+	c, err := Parse(builtinMessages)
+	if err != nil {
+		panic(err)
+	}
+	return c
+})
+
+// Builtin returns the catalog compiled in from messages.json.
+func Builtin() *Catalog {
+	return builtin()
+}
+
+// Chain returns the languages tried for tag, in order: tag itself, then each
+// shorter prefix of it, each followed by its configured fallbacks. The
+// catalog default is not included; Format tries it last.
+func (c *Catalog) Chain(tag string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	var visit func(string)
+	visit = func(t string) {
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		chain = append(chain, t)
+		for _, fb := range c.fallbacks[t] {
+			visit(fb)
+		}
+	}
+	for t := normalize(tag); t != ""; {
+		visit(t)
+		i := strings.LastIndex(t, "-")
+		if i < 0 {
+			break
+		}
+		t = t[:i]
+	}
+	return chain
+}
+
+// Format renders the message key in the first of the preferred languages,
+// or their fallbacks, that has it, ending with the catalog default. It
+// returns the text and the language it is in, or ok false if no language
+// has the message.
+func (c *Catalog) Format(preferred []string, key string, count int, args map[string]string) (text, lang string, ok bool) {
+	for _, t := range c.candidates(preferred) {
+		m, found := c.messages[t][key]
+		if !found {
+			continue
+		}
+		tmpl, found := m[PluralCategory(t, count)]
+		if !found {
+			tmpl = m[Other]
+		}
+		pairs := []string{"{count}", strconv.Itoa(count)}
+		for k, v := range args {
+			pairs = append(pairs, "{"+k+"}", v)
+		}
+		return strings.NewReplacer(pairs...).Replace(tmpl), c.tags[t], true
+	}
+	return "", "", false
+}
+
+// candidates flattens the chains of every preferred language, then the
+// default, without repeats.
+func (c *Catalog) candidates(preferred []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, p := range preferred {
+		for _, t := range c.Chain(p) {
+			if !seen[t] {
+				seen[t] = true
+				out = append(out, t)
+			}
+		}
+	}
+	if !seen[c.def] {
+		out = append(out, c.def)
+	}
+	return out
+}
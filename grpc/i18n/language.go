@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var tagPattern = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// ValidTag reports whether tag is shaped like a BCP 47 language tag, such as
+// "en", "pt-BR" or "zh-Hant-TW". It does not check the subtags are
+// registered.
+func ValidTag(tag string) bool {
+	return tagPattern.MatchString(strings.ReplaceAll(tag, "_", "-"))
+}
+
+// normalize lowercases tag and accepts "_" as a separator, so "pt_BR" and
+// "pt-br" both match a "pt-BR" entry.
+func normalize(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// ParseAcceptLanguage returns the tags in an Accept-Language value, most
+// preferred first. Entries with q=0, the "*" wildcard and malformed tags are
+// dropped.
+func ParseAcceptLanguage(header string) []string {
+	type entry struct {
+		tag string
+		q   float64
+	}
+	var entries []entry
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || !ValidTag(tag) {
+			continue
+		}
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					f = 0
+				}
+				q = f
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		entries = append(entries, entry{tag, q})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+	tags := make([]string, len(entries))
+	for i, e := range entries {
+		tags[i] = e.tag
+	}
+	return tags
+}
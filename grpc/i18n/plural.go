@@ -0,0 +1,56 @@
+package i18n
+
+import "strings"
+
+// Plural categories, named as in the CLDR plural rules. A message has a
+// template for each category its language uses; "other" is always present.
+const (
+	One   = "one"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+// PluralCategory returns the category a non-negative count falls into for
+// the language tag. Only integer counts are handled, which is all the
+// catalog needs.
+func PluralCategory(tag string, n int) string {
+	tag = normalize(tag)
+	base, _, _ := strings.Cut(tag, "-")
+	switch {
+	case tag == "pt-pt":
+		// European Portuguese only uses the singular for exactly one.
+		if n == 1 {
+			return One
+		}
+	case base == "ja" || base == "zh" || base == "ko":
+		// No plural inflection.
+	case base == "fr" || base == "pt":
+		if n == 0 || n == 1 {
+			return One
+		}
+	case base == "ru" || base == "uk":
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return One
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return Few
+		default:
+			return Many
+		}
+	case base == "pl":
+		switch {
+		case n == 1:
+			return One
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return Few
+		default:
+			return Many
+		}
+	default:
+		if n == 1 {
+			return One
+		}
+	}
+	return Other
+}
@@ -0,0 +1,235 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// BreakerState is where a CircuitBreaker is in its cycle.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through and counts failures.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails every call without sending it until the cool-down
+	// has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a few probe calls through to find out whether
+	// the backend has recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// BreakerConfig configures a CircuitBreaker.
+type BreakerConfig struct {
+	// FailureRate is the fraction of failed calls, between 0 and 1, that
+	// opens the breaker.
+	FailureRate float64
+	// MinRequests is how many calls the current window must hold before
+	// FailureRate is checked, so one early failure doesn't open it.
+	MinRequests int
+	// Window is how long calls are counted while closed before the counts
+	// start over.
+	Window time.Duration
+	// CoolDown is how long the breaker stays open before it lets probes
+	// through.
+	CoolDown time.Duration
+	// Probes is how many calls half-open lets through; all of them must
+	// succeed for the breaker to close. Zero means one.
+	Probes int
+	// IsFailure reports whether a call's error says the backend is
+	// unhealthy. Nil counts Unavailable, DeadlineExceeded, Internal and
+	// Unknown; errors the caller caused, such as InvalidArgument or
+	// Canceled, never count.
+	IsFailure func(error) bool
+	// OnStateChange, if set, is called after every transition. It runs on
+	// the goroutine of the call that caused it, outside the breaker's lock.
+	OnStateChange func(from, to BreakerState)
+}
+
+// CircuitBreaker stops a client calling a backend that keeps failing.
+// While closed it counts outcomes; once the failure rate in a window
+// reaches FailureRate it opens and every call fails straight away with
+// codes.Unavailable and a google.rpc.RetryInfo detail. After CoolDown it
+// goes half-open and lets Probes calls through: if they all succeed it
+// closes, if any fails it opens again.
+type CircuitBreaker struct {
+	cfg BreakerConfig
+	now func() time.Time // time.Now, except in tests
+
+	mu          sync.Mutex
+	state       BreakerState
+	gen         uint64 // bumped on every transition
+	windowStart time.Time
+	calls       int
+	failures    int
+	openedAt    time.Time
+	probing     int
+	probed      int
+}
+
+// NewCircuitBreaker returns a closed breaker.
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.Probes <= 0 {
+		cfg.Probes = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = defaultIsFailure
+	}
+	return &CircuitBreaker{cfg: cfg, now: time.Now, windowStart: time.Now()}
+}
+
+func defaultIsFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// State returns the current state, moving from open to half-open if the
+// cool-down is over.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	from, to := b.state, b.advanceLocked(b.now())
+	b.mu.Unlock()
+	b.notify(from, to)
+	return to
+}
+
+// Unary fails calls fast while the breaker is open and records the outcome
+// of the ones it lets through.
+func (b *CircuitBreaker) Unary() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		gen, err := b.allow()
+		if err != nil {
+			return err
+		}
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		b.record(err, gen)
+		return err
+	}
+}
+
+// advanceLocked moves an open breaker whose cool-down is over to half-open
+// and returns the state.
+func (b *CircuitBreaker) advanceLocked(now time.Time) BreakerState {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.cfg.CoolDown {
+		b.setStateLocked(BreakerHalfOpen, now)
+	}
+	return b.state
+}
+
+// allow admits a call, returning the generation it was admitted in, or
+// returns the error to fail it with.
+func (b *CircuitBreaker) allow() (gen uint64, err error) {
+	now := b.now()
+	b.mu.Lock()
+	from := b.state
+	to := b.advanceLocked(now)
+	switch to {
+	case BreakerOpen:
+		err = b.openError(b.cfg.CoolDown - now.Sub(b.openedAt))
+	case BreakerHalfOpen:
+		if b.probing+b.probed >= b.cfg.Probes {
+			err = b.openError(0)
+		} else {
+			b.probing++
+		}
+	}
+	gen = b.gen
+	b.mu.Unlock()
+	b.notify(from, to)
+	return gen, err
+}
+
+// record counts the outcome of a call that allow let through. Outcomes of
+// calls let through in an earlier state, such as one that was still running
+// when the breaker opened, are ignored.
+func (b *CircuitBreaker) record(err error, gen uint64) {
+	failed := err != nil && b.cfg.IsFailure(err)
+	now := b.now()
+	b.mu.Lock()
+	from := b.state
+	switch {
+	case gen != b.gen:
+		// Let through before the last transition; it says nothing about now.
+	case status.Code(err) == codes.Canceled:
+		// The caller gave up, so nothing was learned about the backend.
+		if b.state == BreakerHalfOpen {
+			b.probing--
+		}
+	case b.state == BreakerClosed:
+		if b.cfg.Window > 0 && now.Sub(b.windowStart) >= b.cfg.Window {
+			b.windowStart, b.calls, b.failures = now, 0, 0
+		}
+		b.calls++
+		if failed {
+			b.failures++
+		}
+		if b.failures > 0 && b.calls >= b.cfg.MinRequests && float64(b.failures)/float64(b.calls) >= b.cfg.FailureRate {
+			b.setStateLocked(BreakerOpen, now)
+		}
+	case b.state == BreakerHalfOpen:
+		b.probing--
+		if failed {
+			b.setStateLocked(BreakerOpen, now)
+		} else if b.probed++; b.probed >= b.cfg.Probes {
+			b.setStateLocked(BreakerClosed, now)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// setStateLocked moves to state and resets what is counted in it.
+func (b *CircuitBreaker) setStateLocked(state BreakerState, now time.Time) {
+	b.state = state
+	b.gen++
+	switch state {
+	case BreakerClosed:
+		b.windowStart, b.calls, b.failures = now, 0, 0
+	case BreakerOpen:
+		b.openedAt = now
+	case BreakerHalfOpen:
+		b.probing, b.probed = 0, 0
+	}
+}
+
+func (b *CircuitBreaker) notify(from, to BreakerState) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
+
+// openError is what a call rejected by the breaker fails with. retryAfter
+// is how long until probes are let through, or zero while probes are out.
+func (b *CircuitBreaker) openError(retryAfter time.Duration) error {
+	st := status.New(codes.Unavailable, "circuit breaker is open")
+	ds, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+	return ds.Err()
+}
@@ -0,0 +1,218 @@
+package interceptor
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// breakerUnderTest is a breaker on a fake clock that records its
+// transitions.
+type breakerUnderTest struct {
+	*CircuitBreaker
+	clock *fakeClock
+
+	mu          sync.Mutex
+	transitions []string
+}
+
+func newBreakerUnderTest(cfg BreakerConfig) *breakerUnderTest {
+	bt := &breakerUnderTest{clock: &fakeClock{now: time.Unix(1_000_000, 0)}}
+	cfg.OnStateChange = func(from, to BreakerState) {
+		bt.mu.Lock()
+		bt.transitions = append(bt.transitions, from.String()+"->"+to.String())
+		bt.mu.Unlock()
+	}
+	bt.CircuitBreaker = NewCircuitBreaker(cfg)
+	bt.CircuitBreaker.now = bt.clock.Now
+	return bt
+}
+
+func (bt *breakerUnderTest) Transitions() []string {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	return slices.Clone(bt.transitions)
+}
+
+// call runs one call through the breaker with an invoker that returns err,
+// and reports whether the invoker was reached.
+func (bt *breakerUnderTest) call(err error) (invoked bool, got error) {
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		invoked = true
+		return err
+	}
+	got = bt.Unary()(context.Background(), "/helloworld.Greeter/SayHello", nil, nil, nil, invoker)
+	return invoked, got
+}
+
+// start runs a call whose invoker blocks until the returned func is called
+// with its result. The call has been admitted by the time start returns.
+func (bt *breakerUnderTest) start() (finish func(error)) {
+	admitted := make(chan struct{})
+	result := make(chan error)
+	done := make(chan struct{})
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		close(admitted)
+		return <-result
+	}
+	go func() {
+		defer close(done)
+		bt.Unary()(context.Background(), "/helloworld.Greeter/SayHello", nil, nil, nil, invoker)
+	}()
+	select {
+	case <-admitted:
+	case <-done:
+		panic("call was rejected by the breaker")
+	}
+	return func(err error) {
+		result <- err
+		<-done
+	}
+}
+
+var errBackend = status.Error(codes.Unavailable, "backend down")
+
+func breakerConfig() BreakerConfig {
+	return BreakerConfig{FailureRate: 0.5, MinRequests: 4, CoolDown: 10 * time.Second}
+}
+
+// tripped returns a breaker opened by two failures in four calls.
+func tripped(t *testing.T) *breakerUnderTest {
+	t.Helper()
+	bt := newBreakerUnderTest(breakerConfig())
+	for _, err := range []error{nil, errBackend, nil, errBackend} {
+		bt.call(err)
+	}
+	if got := bt.State(); got != BreakerOpen {
+		t.Fatalf("state after 2 of 4 calls failed is %v, want open", got)
+	}
+	return bt
+}
+
+func TestBreakerTripsOnThreshold(t *testing.T) {
+	bt := newBreakerUnderTest(breakerConfig())
+	// Errors the caller caused count as calls but not as failures.
+	invalid := status.Error(codes.InvalidArgument, "bad name")
+	for i, err := range []error{invalid, invalid, errBackend, nil, errBackend} {
+		bt.call(err)
+		if got := bt.State(); got != BreakerClosed {
+			t.Fatalf("state after call %d is %v, want closed", i+1, got)
+		}
+	}
+	bt.call(errBackend)
+	if got := bt.State(); got != BreakerOpen {
+		t.Fatalf("state after 3 of 6 calls failed is %v, want open", got)
+	}
+	if got, want := bt.Transitions(), []string{"closed->open"}; !slices.Equal(got, want) {
+		t.Errorf("transitions are %v, want %v", got, want)
+	}
+}
+
+func TestBreakerRejectsWhileOpen(t *testing.T) {
+	bt := tripped(t)
+	bt.clock.Advance(4 * time.Second)
+
+	invoked, err := bt.call(nil)
+	if invoked {
+		t.Error("open breaker sent the call")
+	}
+	st := status.Convert(err)
+	if st.Code() != codes.Unavailable {
+		t.Fatalf("open breaker failed the call with %v, want Unavailable", st.Code())
+	}
+	var delay time.Duration
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			delay = info.GetRetryDelay().AsDuration()
+		}
+	}
+	if delay != 6*time.Second {
+		t.Errorf("RetryInfo delay is %v, want the 6s left of the cool-down", delay)
+	}
+}
+
+func TestBreakerHalfOpenAllowsOneProbe(t *testing.T) {
+	bt := tripped(t)
+	bt.clock.Advance(10 * time.Second)
+
+	finish := bt.start()
+	if got := bt.State(); got != BreakerHalfOpen {
+		t.Fatalf("state after the cool-down is %v, want half-open", got)
+	}
+	if invoked, err := bt.call(nil); invoked || status.Code(err) != codes.Unavailable {
+		t.Errorf("second call while the probe is out: invoked %v, err %v; want rejected", invoked, err)
+	}
+	finish(nil)
+	if got := bt.State(); got != BreakerClosed {
+		t.Fatalf("state after a successful probe is %v, want closed", got)
+	}
+	if invoked, err := bt.call(nil); !invoked || err != nil {
+		t.Errorf("call after closing: invoked %v, err %v; want sent", invoked, err)
+	}
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if got := bt.Transitions(); !slices.Equal(got, want) {
+		t.Errorf("transitions are %v, want %v", got, want)
+	}
+}
+
+func TestBreakerReopensOnFailedProbe(t *testing.T) {
+	bt := tripped(t)
+	bt.clock.Advance(10 * time.Second)
+
+	if invoked, _ := bt.call(errBackend); !invoked {
+		t.Fatal("half-open breaker did not send the probe")
+	}
+	if got := bt.State(); got != BreakerOpen {
+		t.Fatalf("state after a failed probe is %v, want open", got)
+	}
+	// The cool-down starts over from the failed probe.
+	bt.clock.Advance(9 * time.Second)
+	if invoked, _ := bt.call(nil); invoked {
+		t.Error("breaker sent a call before the new cool-down ended")
+	}
+}
+
+func TestBreakerIgnoresStaleGeneration(t *testing.T) {
+	bt := newBreakerUnderTest(breakerConfig())
+	// Admitted while closed, and still running when the breaker opens.
+	finishSlow := bt.start()
+	for _, err := range []error{nil, errBackend, nil, errBackend} {
+		bt.call(err)
+	}
+	bt.clock.Advance(10 * time.Second)
+	finishProbe := bt.start()
+
+	// Counted in half-open, this success would close the breaker.
+	finishSlow(nil)
+	if got := bt.State(); got != BreakerHalfOpen {
+		t.Fatalf("state after a stale success is %v, want half-open", got)
+	}
+	finishProbe(errBackend)
+	if got := bt.State(); got != BreakerOpen {
+		t.Fatalf("state after the probe failed is %v, want open", got)
+	}
+}
@@ -0,0 +1,37 @@
+package interceptor
+
+import (
+	"context"
+	"slices"
+
+	"google.golang.org/grpc"
+)
+
+// UnarySendCompressor compresses replies with the named compressor when the
+// client says it can decompress it, whether or not the request itself was
+// compressed. Other clients get the usual behaviour: replies compressed the
+// same way as their request.
+func UnarySendCompressor(name string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		setSendCompressor(ctx, name)
+		return handler(ctx, req)
+	}
+}
+
+// StreamSendCompressor is UnarySendCompressor for streaming RPCs.
+func StreamSendCompressor(name string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		setSendCompressor(ss.Context(), name)
+		return handler(srv, ss)
+	}
+}
+
+func setSendCompressor(ctx context.Context, name string) {
+	supported, err := grpc.ClientSupportedCompressors(ctx)
+	if err != nil || !slices.Contains(supported, name) {
+		return
+	}
+	// Only fails for compressors the server hasn't registered, which
+	// leaves the reply uncompressed.
+	grpc.SetSendCompressor(ctx, name)
+}
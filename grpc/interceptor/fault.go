@@ -0,0 +1,24 @@
+package interceptor
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryFaultInjection fails the given percentage of calls with code before
+// they reach the handler, to exercise client retry and hedging policies.
+// The grpc.* infrastructure services (health, reflection, channelz) are
+// never failed.
+func UnaryFaultInjection(percent float64, code codes.Code) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if percent > 0 && !strings.HasPrefix(info.FullMethod, "/grpc.") && rand.Float64()*100 < percent {
+			return nil, status.Errorf(code, "injected fault")
+		}
+		return handler(ctx, req)
+	}
+}
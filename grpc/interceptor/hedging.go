@@ -0,0 +1,150 @@
+package interceptor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// HedgingPolicy is the hedgingPolicy of a gRPC service config method entry.
+// grpc-go parses retryPolicy itself but ignores hedgingPolicy, so
+// UnaryClientHedging implements it on top of the plain invoker.
+type HedgingPolicy struct {
+	MaxAttempts         int
+	HedgingDelay        time.Duration
+	NonFatalStatusCodes []codes.Code
+}
+
+// ParseHedgingPolicies reads the hedging policies from a service config,
+// keyed by "/service/method", or "/service/" for entries that name a whole
+// service.
+func ParseHedgingPolicies(serviceConfig string) (map[string]HedgingPolicy, error) {
+	var sc struct {
+		MethodConfig []struct {
+			Name []struct {
+				Service string `json:"service"`
+				Method  string `json:"method"`
+			} `json:"name"`
+			HedgingPolicy *struct {
+				MaxAttempts         int          `json:"maxAttempts"`
+				HedgingDelay        string       `json:"hedgingDelay"`
+				NonFatalStatusCodes []codes.Code `json:"nonFatalStatusCodes"`
+			} `json:"hedgingPolicy"`
+		} `json:"methodConfig"`
+	}
+	if err := json.Unmarshal([]byte(serviceConfig), &sc); err != nil {
+		return nil, fmt.Errorf("parse service config: %w", err)
+	}
+	policies := make(map[string]HedgingPolicy)
+	for _, mc := range sc.MethodConfig {
+		hp := mc.HedgingPolicy
+		if hp == nil {
+			continue
+		}
+		var delay time.Duration
+		if hp.HedgingDelay != "" {
+			d, err := time.ParseDuration(hp.HedgingDelay)
+			if err != nil {
+				return nil, fmt.Errorf("parse hedgingDelay: %w", err)
+			}
+			delay = d
+		}
+		for _, n := range mc.Name {
+			policies["/"+n.Service+"/"+n.Method] = HedgingPolicy{
+				MaxAttempts:         hp.MaxAttempts,
+				HedgingDelay:        delay,
+				NonFatalStatusCodes: hp.NonFatalStatusCodes,
+			}
+		}
+	}
+	return policies, nil
+}
+
+// UnaryClientHedging sends up to MaxAttempts copies of a call, starting a new
+// one every HedgingDelay until one succeeds or fails with a fatal code. A
+// non-fatal failure starts the next attempt straight away. The first
+// successful reply wins and the other attempts are cancelled.
+func UnaryClientHedging(policies map[string]HedgingPolicy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p, ok := policies[method]
+		if !ok {
+			p, ok = policies[method[:strings.LastIndex(method, "/")+1]]
+		}
+		out, isProto := reply.(proto.Message)
+		if !ok || p.MaxAttempts < 2 || !isProto {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		type result struct {
+			reply proto.Message
+			err   error
+		}
+		results := make(chan result, p.MaxAttempts)
+		attempt := func() {
+			r := out.ProtoReflect().New().Interface()
+			err := invoker(ctx, method, req, r, cc, opts...)
+			results <- result{r, err}
+		}
+
+		go attempt()
+		started, pending := 1, 1
+		timer := time.NewTimer(p.HedgingDelay)
+		defer timer.Stop()
+		var lastErr error
+		for {
+			select {
+			case r := <-results:
+				pending--
+				if r.err == nil {
+					proto.Reset(out)
+					proto.Merge(out, r.reply)
+					return nil
+				}
+				if !slices.Contains(p.NonFatalStatusCodes, status.Code(r.err)) {
+					return r.err
+				}
+				lastErr = r.err
+				if started < p.MaxAttempts {
+					go attempt()
+					started++
+					pending++
+					resetTimer(timer, p.HedgingDelay)
+				} else if pending == 0 {
+					return lastErr
+				}
+			case <-timer.C:
+				if started < p.MaxAttempts {
+					go attempt()
+					started++
+					pending++
+					timer.Reset(p.HedgingDelay)
+				}
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			}
+		}
+	}
+}
+
+// resetTimer restarts t for d, first discarding a tick that fired but was
+// not received, which under the pre-Go 1.23 timer semantics would
+// otherwise start the next hedge straight away.
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
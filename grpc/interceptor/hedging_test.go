@@ -0,0 +1,190 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"grpc/greetertest"
+	pb "grpc/helloworld"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: "Hello " + in.GetName()}, nil
+}
+
+// flaky numbers the attempts at each name's call from 1. Those fail picks
+// go through UnaryFaultInjection at 100%, and those stall picks are held
+// until the client cancels them.
+type flaky struct {
+	fail, stall func(attempt int) bool
+
+	mu        sync.Mutex
+	attempts  map[string]int
+	cancelled chan string
+}
+
+func newFlaky() *flaky {
+	return &flaky{attempts: make(map[string]int), cancelled: make(chan string, 16)}
+}
+
+func (f *flaky) count(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attempts[name]
+}
+
+func (f *flaky) unary() grpc.UnaryServerInterceptor {
+	inject := UnaryFaultInjection(100, codes.Unavailable)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		name := req.(*pb.HelloRequest).GetName()
+		f.mu.Lock()
+		f.attempts[name]++
+		n := f.attempts[name]
+		f.mu.Unlock()
+		switch {
+		case f.fail != nil && f.fail(n):
+			return inject(ctx, req, info, handler)
+		case f.stall != nil && f.stall(n):
+			select {
+			case <-ctx.Done():
+				f.cancelled <- name
+				return nil, status.FromContextError(ctx.Err()).Err()
+			case <-time.After(10 * time.Second):
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func startHedged(t *testing.T, f *flaky, serviceConfig string) *greetertest.Harness {
+	t.Helper()
+	policies, err := ParseHedgingPolicies(serviceConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := greetertest.Start(greeter{},
+		greetertest.WithUnaryInterceptors(f.unary()),
+		greetertest.WithDialOptions(grpc.WithChainUnaryInterceptor(UnaryClientHedging(policies))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func hedgingConfig(maxAttempts int, delay string) string {
+	return fmt.Sprintf(`{"methodConfig": [{
+		"name": [{"service": "helloworld.Greeter", "method": "SayHello"}],
+		"hedgingPolicy": {"maxAttempts": %d, "hedgingDelay": %q, "nonFatalStatusCodes": ["UNAVAILABLE"]}
+	}]}`, maxAttempts, delay)
+}
+
+func TestHedgingSurvivesFailedAttempts(t *testing.T) {
+	f := newFlaky()
+	// Two of every call's three attempts fail. The delay is long enough
+	// that only a failure starts the next attempt.
+	f.fail = func(n int) bool { return n <= 2 }
+	h := startHedged(t, f, hedgingConfig(3, "60s"))
+
+	for _, name := range []string{"Ana", "Bo", "Cy"} {
+		if _, err := h.Client.SayHello(context.Background(), &pb.HelloRequest{Name: name}); err != nil {
+			t.Errorf("SayHello(%s) failed: %v", name, err)
+		}
+		if got := f.count(name); got != 3 {
+			t.Errorf("SayHello(%s) took %d attempts, want 3", name, got)
+		}
+	}
+}
+
+func TestHedgingCancelsLosingAttempts(t *testing.T) {
+	f := newFlaky()
+	f.stall = func(n int) bool { return n == 1 }
+	h := startHedged(t, f, hedgingConfig(3, "0.02s"))
+
+	start := time.Now()
+	if _, err := h.Client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ana"}); err != nil {
+		t.Fatalf("SayHello failed: %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("SayHello took %v, want the hedge to answer long before the stalled attempt", d)
+	}
+	select {
+	case <-f.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Error("stalled attempt was not cancelled after the hedge won")
+	}
+	if got := f.count("Ana"); got != 2 {
+		t.Errorf("SayHello took %d attempts, want 2", got)
+	}
+}
+
+func TestHedgingStopsAtMaxAttempts(t *testing.T) {
+	f := newFlaky()
+	f.fail = func(int) bool { return true }
+	h := startHedged(t, f, hedgingConfig(3, "0.01s"))
+
+	_, err := h.Client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ana"})
+	if code := status.Code(err); code != codes.Unavailable {
+		t.Errorf("SayHello failed with %v, want Unavailable", code)
+	}
+	// Give any attempt started past the limit time to arrive.
+	time.Sleep(50 * time.Millisecond)
+	if got := f.count("Ana"); got != 3 {
+		t.Errorf("server saw %d attempts, want maxAttempts 3", got)
+	}
+}
+
+func TestRetryPolicyStopsAtMaxAttempts(t *testing.T) {
+	const serviceConfig = `{"methodConfig": [{
+		"name": [{"service": "helloworld.Greeter", "method": "SayHello"}],
+		"retryPolicy": {
+			"maxAttempts": 3,
+			"initialBackoff": "0.01s",
+			"maxBackoff": "0.05s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]}`
+	tests := []struct {
+		name string
+		fail func(int) bool
+		code codes.Code
+	}{
+		{"succeeds on the last attempt", func(n int) bool { return n <= 2 }, codes.OK},
+		{"gives up after maxAttempts", func(int) bool { return true }, codes.Unavailable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFlaky()
+			f.fail = tc.fail
+			h, err := greetertest.Start(greeter{},
+				greetertest.WithUnaryInterceptors(f.unary()),
+				greetertest.WithDialOptions(grpc.WithDefaultServiceConfig(serviceConfig)),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer h.Close()
+
+			_, err = h.Client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ana"})
+			if code := status.Code(err); code != tc.code {
+				t.Errorf("SayHello ended with %v, want %v", code, tc.code)
+			}
+			if got := f.count("Ana"); got != 3 {
+				t.Errorf("server saw %d attempts, want 3", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package interceptor
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+)
+
+// InFlight counts the RPCs currently being handled. Chain it first so the
+// count covers the time spent in every other interceptor too.
+type InFlight struct {
+	n atomic.Int64
+}
+
+// Count returns the number of RPCs that have started and not yet returned.
+func (f *InFlight) Count() int64 {
+	return f.n.Load()
+}
+
+// Unary returns the counting interceptor for unary RPCs.
+func (f *InFlight) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		f.n.Add(1)
+		defer f.n.Add(-1)
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the counting interceptor for streaming RPCs.
+func (f *InFlight) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		f.n.Add(1)
+		defer f.n.Add(-1)
+		return handler(srv, ss)
+	}
+}
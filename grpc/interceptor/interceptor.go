@@ -0,0 +1,36 @@
+// Package interceptor holds gRPC interceptors that any service in this
+// module can chain: request IDs, access logging, panic recovery, fault
+// injection and reply compression on the server, hedging and circuit
+// breaking on the client.
+//
+// On the server, order matters. Request IDs go first so every later
+// interceptor can read them, logging next so it times the whole call, and
+// recovery last so the status it produces is what the logger records:
+//
+//	grpc.ChainUnaryInterceptor(
+//		interceptor.UnaryRequestID(),
+//		interceptor.UnaryLogging(logger),
+//		interceptor.UnaryRecovery(logger),
+//	)
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// wrappedStream lets a stream interceptor hand the handler a derived context.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
+
+// WrapServerStream returns ss with its context replaced by ctx.
+func WrapServerStream(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &wrappedStream{ServerStream: ss, ctx: ctx}
+}
@@ -0,0 +1,71 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDKey is the metadata key carrying the request ID in both
+// directions.
+const RequestIDKey = "x-request-id"
+
+type requestIDCtxKey struct{}
+
+// RequestIDFromContext returns the ID assigned by the request ID
+// interceptors, or "" outside of them.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// UnaryRequestID reuses the caller's x-request-id or generates one, stores it
+// on the context and echoes it back to the caller.
+//
+// Successful calls carry the ID in the response header, failed ones in the
+// trailer: a client that has received headers treats the call as committed
+// and will not retry it, even for a retryable code.
+func UnaryRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, id := withRequestID(ctx)
+		resp, err := handler(ctx, req)
+		if err != nil {
+			grpc.SetTrailer(ctx, metadata.Pairs(RequestIDKey, id))
+		} else {
+			grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, id))
+		}
+		return resp, err
+	}
+}
+
+// StreamRequestID is UnaryRequestID for streaming RPCs. The ID is always
+// returned in the trailer, since headers go out with the first message.
+func StreamRequestID() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, id := withRequestID(ss.Context())
+		ss.SetTrailer(metadata.Pairs(RequestIDKey, id))
+		return handler(srv, WrapServerStream(ss, ctx))
+	}
+}
+
+func withRequestID(ctx context.Context) (context.Context, string) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(RequestIDKey); len(v) > 0 && v[0] != "" {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = newRequestID()
+	}
+	return context.WithValue(ctx, requestIDCtxKey{}, id), id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
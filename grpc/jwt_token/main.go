@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"grpc/auth"
+)
+
+var (
+	key      = flag.String("key", "", "Signing key: a PEM RSA private key (RS256) or an HMAC secret file (HS256)")
+	subject  = flag.String("sub", "local-user", "Token subject")
+	scope    = flag.String("scope", "greeter.hello greeter.chat greeter.history", "Space-separated scopes to grant")
+	issuer   = flag.String("iss", "", "Token issuer")
+	audience = flag.String("aud", "", "Token audience")
+	ttl      = flag.Duration("ttl", time.Hour, "How long the token is valid")
+)
+
+func main() {
+	flag.Parse()
+	k, err := auth.LoadSigningKey(*key)
+	if err != nil {
+		log.Fatalf("failed to load key: %v", err)
+	}
+	token, err := auth.Sign(k, *subject, *scope, *issuer, *audience, *ttl)
+	if err != nil {
+		log.Fatalf("failed to sign token: %v", err)
+	}
+	fmt.Println(token)
+}
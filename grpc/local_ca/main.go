@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"grpc/tlsutil"
+)
+
+var (
+	dir   = flag.String("dir", "certs", "Directory to write the CA, server and client certificates to")
+	hosts = flag.String("hosts", "localhost,127.0.0.1,::1", "Comma-separated names and addresses the server certificate is valid for")
+)
+
+func main() {
+	flag.Parse()
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatalf("failed to create %s: %v", *dir, err)
+	}
+	ca, err := tlsutil.NewLocalCA("greeter local CA")
+	if err != nil {
+		log.Fatalf("failed to create CA: %v", err)
+	}
+	if _, _, err := ca.WriteFiles(*dir, "server", strings.Split(*hosts, ",")...); err != nil {
+		log.Fatalf("failed to issue server certificate: %v", err)
+	}
+	if _, _, err := ca.WriteFiles(*dir, "client"); err != nil {
+		log.Fatalf("failed to issue client certificate: %v", err)
+	}
+	log.Printf("wrote ca.crt, server.crt/key and client.crt/key to %s", *dir)
+}
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// bucket is a token bucket holding up to burst tokens, refilled at rate
+// tokens per second.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take removes one token if there is one. Otherwise it reports how long
+// until the next token is available.
+func (b *bucket) take(l Limit, now time.Time) (bool, time.Duration) {
+	b.refill(l, now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration(math.Ceil((1 - b.tokens) / l.Rate * float64(time.Second)))
+	return false, wait
+}
+
+func (b *bucket) refill(l Limit, now time.Time) {
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+	b.last = now
+}
+
+// full reports whether the bucket would be back at burst by now, in which
+// case forgetting it loses nothing.
+func (b *bucket) full(l Limit, now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*l.Rate >= float64(l.Burst)
+}
@@ -0,0 +1,202 @@
+// Package ratelimit protects a gRPC server from callers that send more than
+// their share. Each caller has a token bucket and a cap on calls in flight
+// per method, so one caller can't use up what others are owed; a call over
+// either limit fails with codes.ResourceExhausted,
+// a google.rpc.RetryInfo detail and a retry-after trailer in whole seconds.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterKey is the trailer telling a rejected caller when to try again.
+const RetryAfterKey = "retry-after"
+
+// Limit configures one method. A zero Rate disables the token bucket and a
+// zero MaxInFlight disables the concurrency cap.
+type Limit struct {
+	// Rate is the sustained number of calls per second allowed per caller.
+	Rate float64
+	// Burst is how many calls a caller can make at once after being idle.
+	Burst int
+	// MaxInFlight caps the calls to the method each caller may have running
+	// at the same time.
+	MaxInFlight int
+}
+
+// KeyFunc identifies the caller a call is charged to.
+type KeyFunc func(ctx context.Context) string
+
+// PeerKey charges calls to the caller's IP address.
+func PeerKey(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// Config sets the limits for a Limiter.
+type Config struct {
+	// Default applies to methods without an entry in Methods.
+	Default Limit
+	// Methods overrides Default by full method name.
+	Methods map[string]Limit
+	// Exempt lists full method name prefixes that are never limited.
+	Exempt []string
+	// Key identifies callers; PeerKey when nil.
+	Key KeyFunc
+}
+
+// callerKey identifies a caller's bucket and in-flight count for a method.
+type callerKey struct {
+	method, caller string
+}
+
+// Limiter enforces a Config. Use Unary and Stream to install it.
+type Limiter struct {
+	cfg Config
+
+	mu        sync.Mutex
+	buckets   map[callerKey]*bucket
+	inFlight  map[callerKey]int
+	lastSweep time.Time
+}
+
+// New returns a Limiter for cfg.
+func New(cfg Config) *Limiter {
+	if cfg.Key == nil {
+		cfg.Key = PeerKey
+	}
+	return &Limiter{
+		cfg:      cfg,
+		buckets:  make(map[callerKey]*bucket),
+		inFlight: make(map[callerKey]int),
+	}
+}
+
+// Unary returns the server interceptor for unary RPCs.
+func (l *Limiter) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		release, wait, err := l.acquire(ctx, info.FullMethod)
+		if err != nil {
+			grpc.SetTrailer(ctx, retryAfter(wait))
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the server interceptor for streaming RPCs. A stream counts
+// as one call for as long as it is open.
+func (l *Limiter) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, wait, err := l.acquire(ss.Context(), info.FullMethod)
+		if err != nil {
+			ss.SetTrailer(retryAfter(wait))
+			return err
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
+
+func (l *Limiter) limitFor(method string) (Limit, bool) {
+	for _, p := range l.cfg.Exempt {
+		if strings.HasPrefix(method, p) {
+			return Limit{}, false
+		}
+	}
+	if lim, ok := l.cfg.Methods[method]; ok {
+		return lim, true
+	}
+	return l.cfg.Default, true
+}
+
+// acquire charges the call and returns a func to call when it finishes, or
+// the error to reject it with and how long the caller should wait.
+func (l *Limiter) acquire(ctx context.Context, method string) (func(), time.Duration, error) {
+	lim, ok := l.limitFor(method)
+	if !ok || (lim.Rate <= 0 && lim.MaxInFlight <= 0) {
+		return func() {}, 0, nil
+	}
+	now := time.Now()
+	k := callerKey{method, l.cfg.Key(ctx)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lim.MaxInFlight > 0 && l.inFlight[k] >= lim.MaxInFlight {
+		return nil, time.Second, rejected(fmt.Sprintf("%d calls to %s already in flight", lim.MaxInFlight, method), time.Second)
+	}
+	if lim.Rate > 0 {
+		l.sweep(now)
+		b, ok := l.buckets[k]
+		if !ok {
+			b = &bucket{tokens: float64(lim.Burst), last: now}
+			l.buckets[k] = b
+		}
+		if ok, wait := b.take(lim, now); !ok {
+			return nil, wait, rejected(fmt.Sprintf("rate limit of %g calls/s exceeded for %s", lim.Rate, method), wait)
+		}
+	}
+	l.inFlight[k]++
+	return func() {
+		l.mu.Lock()
+		// Callers come and go; only those with calls running are kept.
+		if l.inFlight[k]--; l.inFlight[k] == 0 {
+			delete(l.inFlight, k)
+		}
+		l.mu.Unlock()
+	}, 0, nil
+}
+
+// sweep drops buckets that have refilled completely, at most once a minute,
+// so callers that went away don't accumulate. Must hold l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for k, b := range l.buckets {
+		if lim, _ := l.limitFor(k.method); b.full(lim, now) {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+func rejected(msg string, wait time.Duration) error {
+	st := status.New(codes.ResourceExhausted, msg)
+	ds, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return st.Err()
+	}
+	return ds.Err()
+}
+
+func retryAfter(wait time.Duration) metadata.MD {
+	secs := int64(math.Ceil(wait.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	return metadata.Pairs(RetryAfterKey, strconv.FormatInt(secs, 10))
+}
@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"grpc/greetertest"
+	pb "grpc/helloworld"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const sayHello = "/helloworld.Greeter/SayHello"
+
+func TestBucketRefill(t *testing.T) {
+	lim := Limit{Rate: 2, Burst: 2}
+	t0 := time.Unix(1_000_000, 0)
+	b := &bucket{tokens: float64(lim.Burst), last: t0}
+
+	tests := []struct {
+		at   time.Duration
+		ok   bool
+		wait time.Duration
+	}{
+		{0, true, 0},
+		{0, true, 0},
+		{0, false, 500 * time.Millisecond},
+		{250 * time.Millisecond, false, 250 * time.Millisecond},
+		{500 * time.Millisecond, true, 0},
+		// Idle for an hour, the bucket holds no more than Burst.
+		{time.Hour, true, 0},
+		{time.Hour, true, 0},
+		{time.Hour, false, 500 * time.Millisecond},
+	}
+	for i, tc := range tests {
+		ok, wait := b.take(lim, t0.Add(tc.at))
+		if ok != tc.ok || wait != tc.wait {
+			t.Errorf("take %d at +%v = %v, %v; want %v, %v", i+1, tc.at, ok, wait, tc.ok, tc.wait)
+		}
+	}
+}
+
+type callerCtxKey struct{}
+
+// asCaller marks ctx as coming from caller, for a Limiter keyed by
+// contextCaller.
+func asCaller(caller string) context.Context {
+	return context.WithValue(context.Background(), callerCtxKey{}, caller)
+}
+
+func contextCaller(ctx context.Context) string {
+	caller, _ := ctx.Value(callerCtxKey{}).(string)
+	return caller
+}
+
+func TestExemptMethodsAreNeverLimited(t *testing.T) {
+	l := New(Config{
+		Default: Limit{Rate: 1, Burst: 1, MaxInFlight: 1},
+		Exempt:  []string{"/grpc.health.v1.Health/"},
+		Key:     contextCaller,
+	})
+	for range 5 {
+		if _, _, err := l.acquire(asCaller("a"), "/grpc.health.v1.Health/Check"); err != nil {
+			t.Fatalf("exempt health check was limited: %v", err)
+		}
+	}
+	if _, _, err := l.acquire(asCaller("a"), sayHello); err != nil {
+		t.Fatalf("first SayHello was limited: %v", err)
+	}
+	if _, _, err := l.acquire(asCaller("a"), sayHello); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second SayHello ended with %v, want ResourceExhausted", err)
+	}
+}
+
+func TestMaxInFlightIsPerCaller(t *testing.T) {
+	l := New(Config{Default: Limit{MaxInFlight: 2}, Key: contextCaller})
+
+	var releases []func()
+	for range 2 {
+		release, _, err := l.acquire(asCaller("noisy"), sayHello)
+		if err != nil {
+			t.Fatalf("call within the cap was rejected: %v", err)
+		}
+		releases = append(releases, release)
+	}
+	_, wait, err := l.acquire(asCaller("noisy"), sayHello)
+	if status.Code(err) != codes.ResourceExhausted || wait <= 0 {
+		t.Fatalf("third concurrent call = %v, wait %v; want ResourceExhausted with a wait", err, wait)
+	}
+
+	// The noisy caller's calls don't count against anyone else.
+	release, _, err := l.acquire(asCaller("quiet"), sayHello)
+	if err != nil {
+		t.Fatalf("another caller was rejected while the noisy one was at its cap: %v", err)
+	}
+	release()
+	// Nor against the same caller's calls to other methods.
+	release, _, err = l.acquire(asCaller("noisy"), "/helloworld.Greeter/SayHelloAgain")
+	if err != nil {
+		t.Fatalf("call to another method was rejected: %v", err)
+	}
+	release()
+
+	releases[0]()
+	release, _, err = l.acquire(asCaller("noisy"), sayHello)
+	if err != nil {
+		t.Fatalf("call after one finished was rejected: %v", err)
+	}
+	release()
+	releases[1]()
+	if n := len(l.inFlight); n != 0 {
+		t.Errorf("%d in-flight counts left after every call finished, want 0", n)
+	}
+}
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: "Hello " + in.GetName()}, nil
+}
+
+func TestRejectionCarriesRetryAfter(t *testing.T) {
+	l := New(Config{Default: Limit{Rate: 0.5, Burst: 1}})
+	h, err := greetertest.Start(greeter{}, greetertest.WithUnaryInterceptors(l.Unary()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	ctx := context.Background()
+	if _, err := h.Client.SayHello(ctx, &pb.HelloRequest{Name: "Ana"}); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	var trailer metadata.MD
+	_, err = h.Client.SayHello(ctx, &pb.HelloRequest{Name: "Ana"}, grpc.Trailer(&trailer))
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("second call ended with %v, want ResourceExhausted", st.Code())
+	}
+	// One token every two seconds, rounded up to whole seconds.
+	if got := trailer.Get(RetryAfterKey); len(got) != 1 || got[0] != "2" {
+		t.Errorf("%s trailer is %q, want [\"2\"]", RetryAfterKey, got)
+	}
+	var delay time.Duration
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			delay = info.GetRetryDelay().AsDuration()
+		}
+	}
+	if delay <= time.Second || delay > 2*time.Second {
+		t.Errorf("RetryInfo delay is %v, want just under 2s", delay)
+	}
+}
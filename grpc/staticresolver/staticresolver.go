@@ -0,0 +1,154 @@
+// Package staticresolver resolves gRPC targets to a fixed set of backends
+// without DNS or a service mesh:
+//
+//	static:///10.0.0.1:50051,10.0.0.2:50051
+//	file:///etc/greeter/backends.txt
+//
+// The file holds one address per line; blank lines and lines starting with
+// '#' are skipped. It is re-read every poll interval and whenever the
+// channel asks for re-resolution, so replicas can be added or removed
+// without restarting the client. Pair either scheme with the round_robin
+// balancer to spread calls across every address.
+package staticresolver
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// Static returns a builder for the "static" scheme.
+func Static() resolver.Builder {
+	return staticBuilder{}
+}
+
+// File returns a builder for the "file" scheme that polls the file every
+// pollInterval, which must be positive.
+func File(pollInterval time.Duration) resolver.Builder {
+	return fileBuilder{pollInterval: pollInterval}
+}
+
+type staticBuilder struct{}
+
+func (staticBuilder) Scheme() string { return "static" }
+
+func (staticBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	addrs := parseAddrs(strings.Split(target.Endpoint(), ","))
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("staticresolver: no addresses in %q", target.URL.String())
+	}
+	if err := cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		return nil, err
+	}
+	return nopResolver{}, nil
+}
+
+type nopResolver struct{}
+
+func (nopResolver) ResolveNow(resolver.ResolveNowOptions) {}
+func (nopResolver) Close()                                {}
+
+type fileBuilder struct {
+	pollInterval time.Duration
+}
+
+func (fileBuilder) Scheme() string { return "file" }
+
+func (b fileBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	if b.pollInterval <= 0 {
+		return nil, fmt.Errorf("staticresolver: poll interval must be positive, got %v", b.pollInterval)
+	}
+	r := &fileResolver{
+		path:  target.URL.Path,
+		cc:    cc,
+		now:   make(chan struct{}, 1),
+		done:  make(chan struct{}),
+		every: b.pollInterval,
+	}
+	if err := r.resolve(); err != nil {
+		return nil, err
+	}
+	r.wg.Add(1)
+	go r.watch()
+	return r, nil
+}
+
+type fileResolver struct {
+	path  string
+	cc    resolver.ClientConn
+	now   chan struct{}
+	done  chan struct{}
+	every time.Duration
+	wg    sync.WaitGroup
+	last  []byte
+}
+
+func (r *fileResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.now <- struct{}{}:
+	default:
+	}
+}
+
+func (r *fileResolver) Close() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+func (r *fileResolver) watch() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		case <-r.now:
+		}
+		if err := r.resolve(); err != nil {
+			r.cc.ReportError(err)
+		}
+	}
+}
+
+// resolve reads the file and pushes its addresses to the channel if they
+// changed since the last read.
+func (r *fileResolver) resolve() error {
+	b, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("staticresolver: %w", err)
+	}
+	if r.last != nil && bytes.Equal(b, r.last) {
+		return nil
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	addrs := parseAddrs(lines)
+	if len(addrs) == 0 {
+		return fmt.Errorf("staticresolver: no addresses in %s", r.path)
+	}
+	r.last = b
+	return r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
+
+func parseAddrs(lines []string) []resolver.Address {
+	var addrs []resolver.Address
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		addrs = append(addrs, resolver.Address{Addr: l})
+	}
+	return addrs
+}
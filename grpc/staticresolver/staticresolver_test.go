@@ -0,0 +1,173 @@
+package staticresolver
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pb "grpc/helloworld"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+)
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+	id string
+}
+
+func (g greeter) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: "Hello " + in.GetName(), InstanceId: g.id}, nil
+}
+
+// startReplicas serves a greeter on n loopback ports and returns their
+// addresses. Each replies with its address as the instance ID.
+func startReplicas(t *testing.T, n int) []string {
+	t.Helper()
+	var addrs []string
+	for range n {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := grpc.NewServer()
+		pb.RegisterGreeterServer(s, greeter{id: lis.Addr().String()})
+		go s.Serve(lis)
+		t.Cleanup(s.Stop)
+		addrs = append(addrs, lis.Addr().String())
+	}
+	return addrs
+}
+
+func dial(t *testing.T, target string, builders ...resolver.Builder) pb.GreeterClient {
+	t.Helper()
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithResolvers(builders...),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"round_robin": {}}]}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGreeterClient(conn)
+}
+
+// instances makes n calls and counts the replies from each instance.
+func instances(t *testing.T, c pb.GreeterClient, n int) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for range n {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		reply, err := c.SayHello(ctx, &pb.HelloRequest{Name: "Ana"})
+		cancel()
+		if err != nil {
+			t.Fatalf("SayHello failed: %v", err)
+		}
+		counts[reply.GetInstanceId()]++
+	}
+	return counts
+}
+
+// waitFor calls until every one of want has answered, and fails the test if
+// that takes more than five seconds. round_robin only picks connected
+// backends, so the first calls after a change can all land on one.
+func waitFor(t *testing.T, c pb.GreeterClient, want []string) {
+	t.Helper()
+	seen := make(map[string]bool)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		for id := range instances(t, c, 1) {
+			seen[id] = true
+		}
+		if allSeen(seen, want) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("only %v answered, want %v", seen, want)
+}
+
+func allSeen(seen map[string]bool, want []string) bool {
+	for _, w := range want {
+		if !seen[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRoundRobin asserts that calls are spread evenly across want and
+// reach nothing else.
+func checkRoundRobin(t *testing.T, c pb.GreeterClient, want []string) {
+	t.Helper()
+	waitFor(t, c, want)
+	counts := instances(t, c, 10*len(want))
+	for _, w := range want {
+		if counts[w] != 10 {
+			t.Errorf("%d calls went to %s, want an even 10 each; got %v", counts[w], w, counts)
+		}
+	}
+	if len(counts) != len(want) {
+		t.Errorf("calls reached %v, want only %v", counts, want)
+	}
+}
+
+func TestStaticRoundRobin(t *testing.T) {
+	addrs := startReplicas(t, 3)
+	c := dial(t, "static:///"+strings.Join(addrs, ","), Static())
+	checkRoundRobin(t, c, addrs)
+}
+
+func writeAddrs(t *testing.T, path string, addrs ...string) {
+	t.Helper()
+	content := "# greeter replicas\n\n" + strings.Join(addrs, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileRoundRobinFollowsEdits(t *testing.T) {
+	addrs := startReplicas(t, 3)
+	path := filepath.Join(t.TempDir(), "backends.txt")
+	writeAddrs(t, path, addrs[0], addrs[1])
+	c := dial(t, "file://"+path, File(10*time.Millisecond))
+	checkRoundRobin(t, c, addrs[:2])
+
+	writeAddrs(t, path, addrs...)
+	checkRoundRobin(t, c, addrs)
+
+	// Dropping a replica stops calls to it once the file is re-read.
+	writeAddrs(t, path, addrs[2])
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		counts := instances(t, c, 10)
+		if counts[addrs[2]] == 10 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("calls still reach removed replicas: %v", counts)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFileRejectsNonPositivePollInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.txt")
+	writeAddrs(t, path, "127.0.0.1:1")
+	u, err := url.Parse("file://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := resolver.Target{URL: *u}
+	for _, every := range []time.Duration{0, -time.Second} {
+		if _, err := File(every).Build(target, nil, resolver.BuildOptions{}); err == nil {
+			t.Errorf("File(%v) built a resolver, want an error", every)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"grpc/greetertest"
+	pb "grpc/helloworld"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: "Hello " + in.GetName()}, nil
+}
+
+func TestSayHelloIsTracedAndCounted(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	serverTP := NewTracerProvider("greeter_server", exporter)
+	clientTP := NewTracerProvider("greeter_client", exporter)
+	metrics := NewServerMetrics(prometheus.NewRegistry())
+
+	h, err := greetertest.Start(greeter{},
+		greetertest.WithUnaryInterceptors(metrics.Unary()),
+		greetertest.WithServerOptions(grpc.StatsHandler(ServerHandler(serverTP))),
+		greetertest.WithDialOptions(grpc.WithStatsHandler(ClientHandler(clientTP))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	handled := metrics.handled.WithLabelValues("helloworld.Greeter", "SayHello", "OK")
+	before := testutil.ToFloat64(handled)
+	if _, err := h.Client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ana"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(handled) - before; got != 1 {
+		t.Errorf(`grpc_server_handled_total{grpc_method="SayHello",grpc_code="OK"} rose by %v, want 1`, got)
+	}
+
+	ctx := context.Background()
+	if err := clientTP.ForceFlush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := serverTP.ForceFlush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var client, server *tracetest.SpanStub
+	for _, s := range exporter.GetSpans() {
+		switch s.SpanKind {
+		case trace.SpanKindClient:
+			client = &s
+		case trace.SpanKindServer:
+			server = &s
+		}
+	}
+	if client == nil || server == nil {
+		t.Fatalf("got spans %v, want one client and one server span", spanNames(exporter.GetSpans()))
+	}
+	if want := "helloworld.Greeter/SayHello"; server.Name != want {
+		t.Errorf("server span is named %q, want %q", server.Name, want)
+	}
+	if server.Parent.TraceID() != client.SpanContext.TraceID() {
+		t.Errorf("server span is in trace %v, want the client's %v", server.Parent.TraceID(), client.SpanContext.TraceID())
+	}
+	if server.Parent.SpanID() != client.SpanContext.SpanID() {
+		t.Errorf("server span's parent is %v, want the client span %v", server.Parent.SpanID(), client.SpanContext.SpanID())
+	}
+}
+
+func TestNeverSamplesWithoutAnExporter(t *testing.T) {
+	tp := NewTracerProvider("greeter_server", nil)
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	defer span.End()
+	if span.SpanContext().IsSampled() {
+		t.Error("span is sampled by a provider with no exporter")
+	}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.SpanKind.String() + " " + s.Name
+	}
+	return names
+}
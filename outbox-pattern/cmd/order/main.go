@@ -56,7 +56,8 @@ func createOrderHandler(db *sql.DB) func(c *gin.Context) {
 			return
 		}
 
-		_, err = tx.Exec("INSERT INTO outbox (aggregate_id, payload, status) VALUES (?, ?, ?)", order.ID, payload, "pending")
+		_, err = tx.Exec("INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, status) VALUES (?, ?, ?, ?, ?)",
+			"Order", order.ID, "OrderCreated", payload, "pending")
 		if err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
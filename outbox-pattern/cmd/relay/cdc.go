@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/software-architecture-playground/outbox-pattern/db"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+type DebeziumSource struct {
+	Version   string  `json:"version"`
+	Connector string  `json:"connector"`
+	Name      string  `json:"name"`
+	TsMs      int64   `json:"ts_ms"`
+	Snapshot  string  `json:"snapshot"`
+	Db        string  `json:"db"`
+	Sequence  *string `json:"sequence"`
+	Table     string  `json:"table"`
+	ServerID  int64   `json:"server_id"`
+	Gtid      *string `json:"gtid"`
+	File      string  `json:"file"`
+	Pos       int64   `json:"pos"`
+	Row       int32   `json:"row"`
+	Thread    *int64  `json:"thread"`
+	Query     *string `json:"query"`
+}
+
+type DebeziumTransaction struct {
+	ID                  string `json:"id"`
+	TotalOrder          int64  `json:"total_order"`
+	DataCollectionOrder int64  `json:"data_collection_order"`
+}
+
+type DebeziumPayload struct {
+	Before      *Outbox              `json:"before"`
+	After       *Outbox              `json:"after"`
+	Source      DebeziumSource       `json:"source"`
+	Op          string               `json:"op"`
+	TsMs        *int64               `json:"ts_ms"`
+	Transaction *DebeziumTransaction `json:"transaction"`
+}
+
+type DebeziumCDCMessage struct {
+	Schema  interface{}     `json:"schema"`
+	Payload DebeziumPayload `json:"payload"`
+}
+
+// consumer is the part of *kafka.Consumer the CDC relay uses, so a fake can
+// stand in for it to simulate crashes and redeliveries.
+type consumer interface {
+	ReadMessage(timeout time.Duration) (*kafka.Message, error)
+	CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error)
+	Seek(partition kafka.TopicPartition, ignoredTimeoutMs int) error
+}
+
+// cdcRelay publishes the outbox rows Debezium reports as inserted. Offsets
+// are committed by hand once a row is published and marked, so whenever
+// the relay stops, the change event it was working on is read again: an
+// event may be published twice but is never skipped.
+type cdcRelay struct {
+	consumer  consumer
+	publisher *publisher
+	db        execer
+	// retryDelay is how long to wait before reading a message that failed
+	// again.
+	retryDelay time.Duration
+}
+
+// runCDC consumes the change events Debezium publishes for the outbox table
+// and publishes every inserted row.
+func runCDC(ctx context.Context, pub *publisher) {
+	c, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": *brokers,
+		"group.id":          "outbox-relay-group",
+		"auto.offset.reset": "earliest",
+		// Offsets are committed in handle, after the row is marked.
+		"enable.auto.commit": false,
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	err = c.SubscribeTopics([]string{"cdc.outbox_db.outbox"}, nil)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer c.Close()
+
+	relay := &cdcRelay{consumer: c, publisher: pub, db: db.DB, retryDelay: time.Second}
+	relay.run(ctx)
+}
+
+func (r *cdcRelay) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		log.Printf("getting outbox records to process")
+		msg, err := r.consumer.ReadMessage(time.Second)
+		var kafkaErr kafka.Error
+		if err != nil && !(errors.As(err, &kafkaErr) && kafkaErr.IsTimeout()) {
+			// The client will automatically try to recover from all errors.
+			// Timeout is not considered an error because it is raised by
+			// ReadMessage in absence of messages.
+			log.Printf("Consumer error: %v (%v)\n", err, msg)
+			continue
+		}
+
+		if msg == nil {
+			continue
+		}
+
+		if err := r.handle(ctx, msg); err != nil {
+			log.Printf("failed to relay %v, retrying: %v", msg.TopicPartition, err)
+			// Go back rather than past it; later messages wait their turn.
+			if err := r.consumer.Seek(msg.TopicPartition, 0); err != nil {
+				log.Printf("failed to seek back to %v: %v", msg.TopicPartition, err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(r.retryDelay):
+			}
+		}
+	}
+}
+
+// handle publishes the outbox row a change event inserted, marks it
+// published and commits the event's offset. Other events, and ones that
+// can't be decoded, are only committed. An error means the offset was not
+// committed and the event must be handled again.
+func (r *cdcRelay) handle(ctx context.Context, msg *kafka.Message) error {
+	if outbox := outboxInsert(msg); outbox != nil {
+		if _, err := r.publisher.publish(ctx, []*Outbox{outbox}); err != nil {
+			return fmt.Errorf("publish outbox %d: %w", outbox.ID, err)
+		}
+		if err := markPublished(ctx, r.db, outbox.ID); err != nil {
+			return fmt.Errorf("update outbox %d: %w", outbox.ID, err)
+		}
+	}
+	if _, err := r.consumer.CommitMessage(msg); err != nil {
+		return fmt.Errorf("commit offset: %w", err)
+	}
+	return nil
+}
+
+// outboxInsert returns the row a Debezium change event inserted, or nil if
+// it is some other operation or not a change event at all.
+func outboxInsert(msg *kafka.Message) *Outbox {
+	var cdcMessage DebeziumCDCMessage
+	err := json.Unmarshal(msg.Value, &cdcMessage)
+	if err != nil {
+		log.Printf("failed to unmarshal CDC message: %v", err)
+		log.Printf("raw message: %s", string(msg.Value))
+		return nil
+	}
+
+	var outbox *Outbox
+	if cdcMessage.Payload.Op == "c" {
+		outbox = cdcMessage.Payload.After
+	}
+
+	if outbox == nil {
+		log.Printf("no outbox data found in CDC message for operation: %s", cdcMessage.Payload.Op)
+		return nil
+	}
+
+	log.Printf("CDC Operation: %s, Outbox ID: %d, Event: %s, Aggregate: %s %s",
+		cdcMessage.Payload.Op, outbox.ID, outbox.EventType, outbox.AggregateType, outbox.AggregateID)
+	return outbox
+}
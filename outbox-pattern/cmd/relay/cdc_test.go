@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// crash is what a fake panics with to stop the relay mid-step, as if the
+// process had died there.
+type crash struct{ step string }
+
+// world is what outlives a relay crash: the change events on the CDC topic
+// and the consumer group's committed offset, what reached the broker, and
+// which outbox rows are marked published.
+type world struct {
+	t         *testing.T
+	changes   []*kafka.Message
+	inserts   map[kafka.Offset]int64 // outbox ID inserted by the event at an offset
+	committed kafka.Offset
+	produced  map[int64]int
+	marked    map[int64]bool
+
+	// The relay crashes the crashAt'th time it reaches crashStep.
+	crashStep string
+	crashAt   int
+	reached   map[string]int
+
+	// readErrs and deliveryErrs are returned, one per call, before the
+	// fakes behave normally.
+	readErrs     []error
+	deliveryErrs []error
+
+	// stop ends the running relay once the topic is drained.
+	stop context.CancelFunc
+}
+
+func newWorld(t *testing.T) *world {
+	w := &world{
+		t:        t,
+		inserts:  make(map[kafka.Offset]int64),
+		produced: make(map[int64]int),
+		marked:   make(map[int64]bool),
+		reached:  make(map[string]int),
+	}
+	topic := "cdc.outbox_db.outbox"
+	add := func(payload DebeziumPayload) {
+		value, err := json.Marshal(DebeziumCDCMessage{Payload: payload})
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset := kafka.Offset(len(w.changes))
+		if payload.Op == "c" {
+			w.inserts[offset] = payload.After.ID
+		}
+		w.changes = append(w.changes, &kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topic, Offset: offset},
+			Value:          value,
+		})
+	}
+	add(DebeziumPayload{Op: "c", After: &Outbox{ID: 1, AggregateType: "Order", AggregateID: "o-1", EventType: "OrderCreated", Payload: "{}"}})
+	add(DebeziumPayload{Op: "c", After: &Outbox{ID: 2, AggregateType: "Order", AggregateID: "o-2", EventType: "OrderCreated", Payload: "{}"}})
+	add(DebeziumPayload{Op: "u", After: &Outbox{ID: 1, Status: "published"}})
+	add(DebeziumPayload{Op: "c", After: &Outbox{ID: 3, AggregateType: "Order", AggregateID: "o-1", EventType: "OrderCancelled", Payload: "{}"}})
+	return w
+}
+
+func (w *world) step(name string) {
+	w.reached[name]++
+	if name == w.crashStep && w.reached[name] == w.crashAt {
+		panic(crash{name})
+	}
+}
+
+type fakeConsumer struct {
+	w   *world
+	pos kafka.Offset
+}
+
+func (c *fakeConsumer) ReadMessage(time.Duration) (*kafka.Message, error) {
+	if len(c.w.readErrs) > 0 {
+		err := c.w.readErrs[0]
+		c.w.readErrs = c.w.readErrs[1:]
+		return nil, err
+	}
+	if int(c.pos) >= len(c.w.changes) {
+		c.w.stop()
+		return nil, kafka.NewError(kafka.ErrTimedOut, "no messages", false)
+	}
+	msg := c.w.changes[c.pos]
+	c.pos++
+	c.w.step("read")
+	return msg, nil
+}
+
+func (c *fakeConsumer) CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error) {
+	if id, ok := c.w.inserts[m.TopicPartition.Offset]; ok && !c.w.marked[id] {
+		c.w.t.Errorf("offset %d committed before outbox %d was marked published", m.TopicPartition.Offset, id)
+	}
+	c.w.committed = m.TopicPartition.Offset + 1
+	return nil, nil
+}
+
+func (c *fakeConsumer) Seek(tp kafka.TopicPartition, _ int) error {
+	c.pos = tp.Offset
+	return nil
+}
+
+type fakeProducer struct{ w *world }
+
+func (p *fakeProducer) Produce(m *kafka.Message, deliveryChan chan kafka.Event) error {
+	for _, h := range m.Headers {
+		if h.Key == outboxIDHeader {
+			id, err := strconv.ParseInt(string(h.Value), 10, 64)
+			if err != nil {
+				return err
+			}
+			p.w.produced[id]++
+		}
+	}
+	// The message has reached the broker but its ack has not come back.
+	p.w.step("produce")
+	if len(p.w.deliveryErrs) > 0 {
+		m.TopicPartition.Error = p.w.deliveryErrs[0]
+		p.w.deliveryErrs = p.w.deliveryErrs[1:]
+	}
+	deliveryChan <- m
+	return nil
+}
+
+func (p *fakeProducer) Flush(int) int { return 0 }
+func (p *fakeProducer) Close()        {}
+
+type fakeDB struct{ w *world }
+
+func (db *fakeDB) ExecContext(_ context.Context, _ string, args ...any) (sql.Result, error) {
+	for _, arg := range args[1:] {
+		db.w.marked[arg.(int64)] = true
+	}
+	// The rows are marked but the offset has not been committed.
+	db.w.step("mark")
+	return driver.RowsAffected(len(args) - 1), nil
+}
+
+// runRelay runs a relay from the committed offset until the topic is
+// drained, and reports whether it crashed first.
+func (w *world) runRelay() (crashed bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.stop = cancel
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(crash); !ok {
+				panic(r)
+			}
+			crashed = true
+		}
+	}()
+	relay := &cdcRelay{
+		consumer:  &fakeConsumer{w: w, pos: w.committed},
+		publisher: &publisher{producer: &fakeProducer{w: w}, router: &router{fallback: "outbox.event.{aggregate_type}"}},
+		db:        &fakeDB{w: w},
+	}
+	relay.run(ctx)
+	return false
+}
+
+// runUntilDrained restarts the relay after every crash until it gets
+// through the whole topic.
+func (w *world) runUntilDrained() {
+	w.t.Helper()
+	for range 10 {
+		if !w.runRelay() {
+			return
+		}
+	}
+	w.t.Fatal("relay still crashing after 10 restarts")
+}
+
+// checkDelivered asserts every inserted row reached the broker, is marked
+// published, and that the whole topic was committed.
+func (w *world) checkDelivered() {
+	w.t.Helper()
+	for _, id := range w.inserts {
+		if w.produced[id] == 0 {
+			w.t.Errorf("outbox %d was never produced", id)
+		}
+		if !w.marked[id] {
+			w.t.Errorf("outbox %d was never marked published", id)
+		}
+	}
+	if int(w.committed) != len(w.changes) {
+		w.t.Errorf("committed offset is %d, want %d", w.committed, len(w.changes))
+	}
+}
+
+func TestCDCRelaySurvivesCrashes(t *testing.T) {
+	steps := []struct {
+		step, desc string
+	}{
+		{"read", "after ReadMessage"},
+		{"produce", "after Produce but before the ack"},
+		{"mark", "after markPublished but before CommitMessage"},
+	}
+	for _, s := range steps {
+		for at := 1; at <= 3; at++ {
+			t.Run(fmt.Sprintf("crash %s #%d", s.desc, at), func(t *testing.T) {
+				w := newWorld(t)
+				w.crashStep, w.crashAt = s.step, at
+				w.runUntilDrained()
+				if w.reached[s.step] < at {
+					t.Fatalf("relay reached %s %d times, never crashed", s.step, w.reached[s.step])
+				}
+				w.checkDelivered()
+			})
+		}
+	}
+}
+
+func TestCDCRelayRetriesFailedDeliveries(t *testing.T) {
+	w := newWorld(t)
+	w.deliveryErrs = []error{kafka.NewError(kafka.ErrMsgTimedOut, "message timed out", false)}
+	w.runUntilDrained()
+	w.checkDelivered()
+	if w.produced[1] != 2 {
+		t.Errorf("outbox 1 was produced %d times, want 2", w.produced[1])
+	}
+}
+
+func TestCDCRelaySkipsConsumerErrors(t *testing.T) {
+	w := newWorld(t)
+	w.readErrs = []error{
+		kafka.NewError(kafka.ErrTransport, "broker down", false),
+		errors.New("not a kafka.Error"),
+	}
+	w.runUntilDrained()
+	w.checkDelivered()
+}
@@ -2,132 +2,87 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
+	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/software-architecture-playground/outbox-pattern/db"
-
-	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 )
 
-type Outbox struct {
-	ID          int64   `json:"id"`
-	AggregateID string  `json:"aggregate_id"`
-	Payload     string  `json:"payload"`
-	Status      string  `json:"status"`
-	CreatedAt   string  `json:"created_at"`
-	PublishedAt *string `json:"published_at"`
-}
-
-type DebeziumSource struct {
-	Version   string  `json:"version"`
-	Connector string  `json:"connector"`
-	Name      string  `json:"name"`
-	TsMs      int64   `json:"ts_ms"`
-	Snapshot  string  `json:"snapshot"`
-	Db        string  `json:"db"`
-	Sequence  *string `json:"sequence"`
-	Table     string  `json:"table"`
-	ServerID  int64   `json:"server_id"`
-	Gtid      *string `json:"gtid"`
-	File      string  `json:"file"`
-	Pos       int64   `json:"pos"`
-	Row       int32   `json:"row"`
-	Thread    *int64  `json:"thread"`
-	Query     *string `json:"query"`
-}
-
-type DebeziumTransaction struct {
-	ID                  string `json:"id"`
-	TotalOrder          int64  `json:"total_order"`
-	DataCollectionOrder int64  `json:"data_collection_order"`
-}
+var (
+	brokers = flag.String("brokers", "localhost:9092", "Kafka bootstrap servers, comma-separated")
+	topic   = flag.String("topic", "outbox.event.{aggregate_type}", "Topic for events -routes has no entry for; {aggregate_type} and {event_type} are replaced with the event's")
+	routes  = flag.String("routes", "", "Routing table as AggregateType=topic or AggregateType.EventType=topic, comma-separated; the event type entry wins")
 
-type DebeziumPayload struct {
-	Before      *Outbox              `json:"before"`
-	After       *Outbox              `json:"after"`
-	Source      DebeziumSource       `json:"source"`
-	Op          string               `json:"op"`
-	TsMs        *int64               `json:"ts_ms"`
-	Transaction *DebeziumTransaction `json:"transaction"`
-}
+	mode         = flag.String("mode", "cdc", "How pending outbox rows are found: cdc reads Debezium change events from Kafka, poll queries the outbox table")
+	pollInterval = flag.Duration("poll_interval", time.Second, "How long -mode=poll waits after a batch that was not full before polling again")
+	batchSize    = flag.Int("batch_size", 100, "Outbox rows -mode=poll locks and publishes per transaction, at most one per aggregate")
+)
 
-type DebeziumCDCMessage struct {
-	Schema  interface{}     `json:"schema"`
-	Payload DebeziumPayload `json:"payload"`
+type Outbox struct {
+	ID            int64   `json:"id"`
+	AggregateType string  `json:"aggregate_type"`
+	AggregateID   string  `json:"aggregate_id"`
+	EventType     string  `json:"event_type"`
+	Payload       string  `json:"payload"`
+	Headers       *string `json:"headers"`
+	Status        string  `json:"status"`
+	CreatedAt     string  `json:"created_at"`
+	PublishedAt   *string `json:"published_at"`
 }
 
 func main() {
-	c, err := kafka.NewConsumer(&kafka.ConfigMap{
-		"bootstrap.servers": "localhost:9092",
-		"group.id":          "outbox-relay-group",
-		"auto.offset.reset": "earliest",
-	})
+	flag.Parse()
 
-	if err != nil {
-		panic(err)
+	if err := db.Init(); err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
 	}
+	defer db.Close()
 
-	err = c.SubscribeTopics([]string{"cdc.outbox_db.outbox"}, nil)
-
+	router, err := parseRoutes(*routes, *topic)
 	if err != nil {
-		panic(err)
+		log.Fatalf("invalid -routes: %v", err)
 	}
+	pub, err := newPublisher(*brokers, router)
+	if err != nil {
+		log.Fatalf("failed to create Kafka producer: %v", err)
+	}
+	defer pub.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch *mode {
+	case "cdc":
+		runCDC(ctx, pub)
+	case "poll":
+		runPoll(ctx, pub, *pollInterval, *batchSize)
+	default:
+		log.Fatalf("unknown mode %q, want cdc or poll", *mode)
+	}
+}
 
-	db.Init()
-	defer db.Close()
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer func() {
-		cancel()
-		c.Close()
-	}()
-
-	for {
-		log.Printf("getting outbox records to process")
-		msg, err := c.ReadMessage(time.Second)
-		if err != nil && !err.(kafka.Error).IsTimeout() {
-			// The client will automatically try to recover from all errors.
-			// Timeout is not considered an error because it is raised by
-			// ReadMessage in absence of messages.
-			log.Printf("Consumer error: %v (%v)\n", err, msg)
-			continue
-		}
-
-		if msg == nil {
-			continue
-		}
-
-		var cdcMessage DebeziumCDCMessage
-		err = json.Unmarshal(msg.Value, &cdcMessage)
-		if err != nil {
-			log.Printf("failed to unmarshal CDC message: %v", err)
-			log.Printf("raw message: %s", string(msg.Value))
-			continue
-		}
-
-		var outbox *Outbox
-		if cdcMessage.Payload.Op == "c" {
-			outbox = cdcMessage.Payload.After
-		}
-
-		if outbox == nil {
-			log.Printf("no outbox data found in CDC message for operation: %s", cdcMessage.Payload.Op)
-			continue
-		}
-
-		log.Printf("CDC Operation: %s, Order ID: %d, Aggregate: %s",
-			cdcMessage.Payload.Op, outbox.ID, outbox.AggregateID)
-		log.Printf("Outbox payload (business event): %s", outbox.Payload)
-
-		time.Sleep(1 * time.Second)
+// execer runs the relay's updates: *sql.DB, or the *sql.Tx a batch was
+// locked in.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
 
-		published_at := time.Now().Format(time.RFC3339)
-		_, err = db.DB.ExecContext(ctx, `UPDATE outbox SET status = 'published', published_at = ? WHERE id = ?`, published_at, outbox.ID)
-		if err != nil {
-			log.Printf("failed to update outbox %d: %v", outbox.ID, err)
-			continue
-		}
+// markPublished marks outbox rows published. Rows already published are
+// left as they are, so marking an event that was published again after a
+// crash changes nothing and keeps its first published_at.
+func markPublished(ctx context.Context, db execer, ids ...int64) error {
+	args := []any{time.Now()}
+	for _, id := range ids {
+		args = append(args, id)
 	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	_, err := db.ExecContext(ctx, `UPDATE outbox SET status = 'published', published_at = ?
+		WHERE id IN (`+placeholders+`) AND status = 'pending'`, args...)
+	return err
 }
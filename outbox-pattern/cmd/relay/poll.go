@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/software-architecture-playground/outbox-pattern/db"
+)
+
+// runPoll publishes pending outbox rows by querying the table directly, for
+// environments without Debezium and Kafka Connect. A batch that published
+// anything is followed straight away by the next one, so a backlog drains
+// without waiting for the interval even though each batch takes only one
+// event per aggregate.
+func runPoll(ctx context.Context, pub *publisher, interval time.Duration, batchSize int) {
+	for ctx.Err() == nil {
+		n, err := pollBatch(ctx, db.DB, pub, batchSize)
+		if err != nil {
+			log.Printf("failed to poll outbox: %v", err)
+		}
+		if n > 0 {
+			log.Printf("published %d outbox records", n)
+		}
+		if err == nil && n > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+	}
+}
+
+// pollBatch locks up to batchSize pending rows, oldest first, publishes
+// them and marks them published in the same transaction. SKIP LOCKED lets
+// several relays poll at once without waiting on, or publishing, each
+// other's rows. It returns how many rows were published.
+//
+// Only the oldest pending row of each aggregate is taken. Were a later one
+// taken too, a relay could skip an event another relay has locked and
+// publish the aggregate's next event ahead of it. The NOT EXISTS is a plain
+// consistent read, as only o is locked, so a row another relay holds still
+// counts as pending and keeps the events after it back.
+//
+// Only rows the broker acknowledged are marked, up to the first one it
+// didn't; the rest stay pending for the next poll.
+func pollBatch(ctx context.Context, conn *sql.DB, pub *publisher, batchSize int) (int, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Ordered the same way as idx_outbox_pending, which InnoDB extends with
+	// the primary key, so the scan reads the index in order. The subquery
+	// looks up earlier rows of the aggregate through idx_outbox_aggregate;
+	// IDs follow insert order.
+	rows, err := tx.QueryContext(ctx, `
+		SELECT o.id, o.aggregate_type, o.aggregate_id, o.event_type, o.payload, o.headers, o.status, o.created_at
+		FROM outbox o
+		WHERE o.status = 'pending'
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox e
+			WHERE e.aggregate_id = o.aggregate_id AND e.status = 'pending' AND e.id < o.id)
+		ORDER BY o.created_at, o.id
+		LIMIT ?
+		FOR UPDATE OF o SKIP LOCKED`, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var batch []*Outbox
+	for rows.Next() {
+		outbox := &Outbox{}
+		var createdAt time.Time
+		if err := rows.Scan(&outbox.ID, &outbox.AggregateType, &outbox.AggregateID, &outbox.EventType,
+			&outbox.Payload, &outbox.Headers, &outbox.Status, &createdAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		outbox.CreatedAt = createdAt.Format(time.RFC3339Nano)
+		batch = append(batch, outbox)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	acked, err := pub.publish(ctx, batch)
+	if err != nil {
+		log.Printf("failed to publish outbox batch: %v", err)
+	}
+	if acked == 0 {
+		return 0, nil
+	}
+	ids := make([]int64, acked)
+	for i, outbox := range batch[:acked] {
+		ids[i] = outbox.ID
+	}
+	if err := markPublished(ctx, tx, ids...); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return acked, nil
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// outboxTable is an in-memory outbox behind database/sql, shared by every
+// relay in a test. It models the two statements poll mode sends: the batch
+// SELECT, with row locks held until commit, and markPublished's UPDATE,
+// which other transactions see once it commits. The SELECT's SKIP LOCKED
+// and NOT EXISTS clauses are each modelled only when the query has them.
+type outboxTable struct {
+	mu    sync.Mutex
+	rows  []*Outbox // ordered by ID, which is also creation order here
+	locks map[int64]*outboxTx
+}
+
+func newOutboxTable(rows ...*Outbox) *outboxTable {
+	for _, row := range rows {
+		row.Status = "pending"
+	}
+	return &outboxTable{rows: rows, locks: make(map[int64]*outboxTx)}
+}
+
+func (tbl *outboxTable) open() *sql.DB { return sql.OpenDB(tbl) }
+
+func (tbl *outboxTable) Connect(context.Context) (driver.Conn, error) {
+	return &outboxConn{tbl: tbl}, nil
+}
+
+func (tbl *outboxTable) Driver() driver.Driver { return nil }
+
+// pending reports the IDs of rows not yet marked published.
+func (tbl *outboxTable) pending() []int64 {
+	tbl.mu.Lock()
+	defer tbl.mu.Unlock()
+	var ids []int64
+	for _, row := range tbl.rows {
+		if row.Status == "pending" {
+			ids = append(ids, row.ID)
+		}
+	}
+	return ids
+}
+
+// headOfAggregate reports whether row is the oldest pending row of its
+// aggregate, going by committed statuses.
+func (tbl *outboxTable) headOfAggregate(row *Outbox) bool {
+	for _, e := range tbl.rows {
+		if e.AggregateID == row.AggregateID && e.Status == "pending" && e.ID < row.ID {
+			return false
+		}
+	}
+	return true
+}
+
+type outboxConn struct {
+	tbl *outboxTable
+	tx  *outboxTx
+}
+
+type outboxTx struct {
+	conn   *outboxConn
+	marked []int64
+}
+
+func (c *outboxConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("outboxTable does not prepare statements")
+}
+
+func (c *outboxConn) Close() error { return nil }
+
+func (c *outboxConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *outboxConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.tx = &outboxTx{conn: c}
+	return c.tx, nil
+}
+
+func (c *outboxConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.tx == nil || !strings.Contains(query, "FOR UPDATE") {
+		return nil, fmt.Errorf("outboxTable only models the locking batch query, got %q", query)
+	}
+	limit := int(args[0].Value.(int64))
+	skipLocked := strings.Contains(query, "SKIP LOCKED")
+	headsOnly := strings.Contains(query, "NOT EXISTS")
+
+	tbl := c.tbl
+	tbl.mu.Lock()
+	defer tbl.mu.Unlock()
+	var batch []*Outbox
+	for _, row := range tbl.rows {
+		if len(batch) == limit {
+			break
+		}
+		if row.Status != "pending" || (headsOnly && !tbl.headOfAggregate(row)) {
+			continue
+		}
+		if owner, ok := tbl.locks[row.ID]; ok && owner != c.tx {
+			if skipLocked {
+				continue
+			}
+			return nil, errors.New("outboxTable does not model waiting on a lock")
+		}
+		tbl.locks[row.ID] = c.tx
+		copied := *row
+		batch = append(batch, &copied)
+	}
+	return &outboxRows{batch: batch}, nil
+}
+
+func (c *outboxConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.tx == nil || !strings.HasPrefix(query, "UPDATE outbox SET status = 'published'") {
+		return nil, fmt.Errorf("outboxTable only models markPublished, got %q", query)
+	}
+	for _, arg := range args[1:] {
+		c.tx.marked = append(c.tx.marked, arg.Value.(int64))
+	}
+	return driver.RowsAffected(len(args) - 1), nil
+}
+
+func (tx *outboxTx) Commit() error {
+	tbl := tx.conn.tbl
+	tbl.mu.Lock()
+	for _, row := range tbl.rows {
+		if slices.Contains(tx.marked, row.ID) {
+			row.Status = "published"
+		}
+	}
+	tbl.mu.Unlock()
+	return tx.Rollback()
+}
+
+func (tx *outboxTx) Rollback() error {
+	tbl := tx.conn.tbl
+	tbl.mu.Lock()
+	for id, owner := range tbl.locks {
+		if owner == tx {
+			delete(tbl.locks, id)
+		}
+	}
+	tbl.mu.Unlock()
+	tx.conn.tx = nil
+	return nil
+}
+
+type outboxRows struct {
+	batch []*Outbox
+}
+
+func (r *outboxRows) Columns() []string {
+	return []string{"id", "aggregate_type", "aggregate_id", "event_type", "payload", "headers", "status", "created_at"}
+}
+
+func (r *outboxRows) Close() error { return nil }
+
+func (r *outboxRows) Next(dest []driver.Value) error {
+	if len(r.batch) == 0 {
+		return io.EOF
+	}
+	row := r.batch[0]
+	r.batch = r.batch[1:]
+	copy(dest, []driver.Value{row.ID, row.AggregateType, row.AggregateID, row.EventType, row.Payload, nil, row.Status, time.Now()})
+	return nil
+}
+
+// broker records the outbox IDs produced by every relay, in order.
+type broker struct {
+	mu       sync.Mutex
+	produced []int64
+	// failures are how many more times each ID's delivery fails.
+	failures map[int64]int
+}
+
+func (b *broker) publisher(beforeProduce func(id int64)) *publisher {
+	return &publisher{
+		producer: &pollProducer{broker: b, beforeProduce: beforeProduce},
+		router:   &router{fallback: "outbox.event.{aggregate_type}"},
+	}
+}
+
+func (b *broker) log() []int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.produced)
+}
+
+type pollProducer struct {
+	broker        *broker
+	beforeProduce func(id int64)
+}
+
+func (p *pollProducer) Produce(m *kafka.Message, deliveryChan chan kafka.Event) error {
+	var id int64
+	for _, h := range m.Headers {
+		if h.Key == outboxIDHeader {
+			id, _ = strconv.ParseInt(string(h.Value), 10, 64)
+		}
+	}
+	if p.beforeProduce != nil {
+		p.beforeProduce(id)
+	}
+	b := p.broker
+	b.mu.Lock()
+	b.produced = append(b.produced, id)
+	if b.failures[id] > 0 {
+		b.failures[id]--
+		m.TopicPartition.Error = kafka.NewError(kafka.ErrMsgTimedOut, "message timed out", false)
+	}
+	b.mu.Unlock()
+	deliveryChan <- m
+	return nil
+}
+
+func (p *pollProducer) Flush(int) int { return 0 }
+func (p *pollProducer) Close()        {}
+
+func event(id int64, aggregateID string) *Outbox {
+	return &Outbox{ID: id, AggregateType: "Order", AggregateID: aggregateID, EventType: "OrderUpdated", Payload: "{}"}
+}
+
+// checkAggregateOrder asserts that each aggregate's events were first
+// produced in ID order.
+func checkAggregateOrder(t *testing.T, tbl *outboxTable, produced []int64) {
+	t.Helper()
+	aggregate := make(map[int64]string)
+	for _, row := range tbl.rows {
+		aggregate[row.ID] = row.AggregateID
+	}
+	last := make(map[string]int64)
+	seen := make(map[int64]bool)
+	for _, id := range produced {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if prev := last[aggregate[id]]; id < prev {
+			t.Errorf("outbox %d of %s was produced after outbox %d; order %v", id, aggregate[id], prev, produced)
+		}
+		last[aggregate[id]] = id
+	}
+}
+
+// drain polls with each relay in turn until none publishes anything.
+func drain(t *testing.T, db *sql.DB, pubs ...*publisher) {
+	t.Helper()
+	for range 20 {
+		total := 0
+		for _, pub := range pubs {
+			n, err := pollBatch(context.Background(), db, pub, 10)
+			if err != nil {
+				t.Fatalf("pollBatch failed: %v", err)
+			}
+			total += n
+		}
+		if total == 0 {
+			return
+		}
+	}
+	t.Fatal("relays still publishing after 20 rounds")
+}
+
+func TestPollKeepsAggregateOrderAcrossRelays(t *testing.T) {
+	tbl := newOutboxTable(event(1, "o-1"), event(2, "o-2"), event(3, "o-1"), event(4, "o-2"), event(5, "o-1"))
+	db := tbl.open()
+	defer db.Close()
+	b := &broker{}
+
+	// Relay A takes a batch of two and stops at its first event with the
+	// batch locked.
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	pubA := b.publisher(func(int64) {
+		once.Do(func() {
+			close(locked)
+			<-release
+		})
+	})
+	pubB := b.publisher(nil)
+	done := make(chan int)
+	go func() {
+		n, err := pollBatch(context.Background(), db, pubA, 2)
+		if err != nil {
+			t.Errorf("relay A's pollBatch failed: %v", err)
+		}
+		done <- n
+	}()
+	<-locked
+
+	// Both aggregates' oldest events are A's, so B must leave the later
+	// ones alone rather than publish them first.
+	if n, err := pollBatch(context.Background(), db, pubB, 10); n != 0 || err != nil {
+		t.Errorf("relay B published %d events while A held both aggregates' oldest, err %v; want 0", n, err)
+	}
+	close(release)
+	if n := <-done; n != 2 {
+		t.Errorf("relay A published %d events, want the oldest of each aggregate", n)
+	}
+
+	drain(t, db, pubB, pubA)
+	if ids := tbl.pending(); len(ids) != 0 {
+		t.Errorf("outbox %v still pending", ids)
+	}
+	produced := b.log()
+	if len(produced) != 5 {
+		t.Errorf("produced %v, want each of the 5 events once", produced)
+	}
+	checkAggregateOrder(t, tbl, produced)
+}
+
+func TestPollRetriesUnacknowledgedEvents(t *testing.T) {
+	tbl := newOutboxTable(event(1, "o-1"), event(2, "o-2"), event(3, "o-3"), event(4, "o-1"))
+	db := tbl.open()
+	defer db.Close()
+	b := &broker{failures: map[int64]int{2: 1}}
+	pub := b.publisher(nil)
+
+	n, err := pollBatch(context.Background(), db, pub, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 got through, but is held back with 2 so it isn't marked ahead of it.
+	if n != 1 {
+		t.Errorf("first poll published %d events, want only the one before the failure", n)
+	}
+	if got, want := tbl.pending(), []int64{2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("pending after the failed delivery = %v, want %v", got, want)
+	}
+
+	drain(t, db, pub)
+	if ids := tbl.pending(); len(ids) != 0 {
+		t.Errorf("outbox %v still pending", ids)
+	}
+	if got, want := b.log(), []int64{1, 2, 3, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("produced %v, want %v", got, want)
+	}
+	checkAggregateOrder(t, tbl, b.log())
+}
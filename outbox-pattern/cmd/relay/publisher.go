@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const (
+	// outboxIDHeader carries the outbox row ID, which consumers can use to
+	// drop the duplicates an at-least-once relay may send.
+	outboxIDHeader = "outbox_id"
+	// eventTypeHeader tells consumers of a topic shared by several event
+	// types which one a message is.
+	eventTypeHeader = "event_type"
+)
+
+// producer is the part of *kafka.Producer the publisher uses, so a fake can
+// stand in for it to simulate failed deliveries.
+type producer interface {
+	Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error
+	Flush(timeoutMs int) int
+	Close()
+}
+
+// publisher produces outbox events to the Kafka topics its router picks.
+type publisher struct {
+	producer producer
+	router   *router
+}
+
+func newPublisher(brokers string, router *router) (*publisher, error) {
+	p, err := kafka.NewProducer(&kafka.ConfigMap{
+		"bootstrap.servers": brokers,
+		// Waits for all in-sync replicas and keeps retried messages in
+		// order within a partition.
+		"enable.idempotence": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Delivery reports go to the channel passed to Produce; only errors
+	// and stats come through here.
+	go func() {
+		for e := range p.Events() {
+			if err, ok := e.(kafka.Error); ok {
+				log.Printf("Producer error: %v", err)
+			}
+		}
+	}()
+	return &publisher{producer: p, router: router}, nil
+}
+
+// Close waits briefly for anything still in flight, then closes the
+// producer.
+func (p *publisher) Close() {
+	p.producer.Flush(5000)
+	p.producer.Close()
+}
+
+// publish produces the events in order and waits for the broker to
+// acknowledge them. Each is keyed by its aggregate ID, so all events for an
+// aggregate land on one partition and stay in order.
+//
+// It returns how many events from the start of the slice were
+// acknowledged. Only those may be marked published; an event after a
+// failed one is sent again later even if it got through, rather than
+// getting ahead of it.
+func (p *publisher) publish(ctx context.Context, events []*Outbox) (int, error) {
+	delivery := make(chan kafka.Event, len(events))
+	results := make([]error, len(events))
+	produced := 0
+	var produceErr error
+	for i, outbox := range events {
+		if err := p.producer.Produce(p.message(outbox, i), delivery); err != nil {
+			produceErr = fmt.Errorf("produce outbox %d: %w", outbox.ID, err)
+			break
+		}
+		produced++
+	}
+
+	for pending := produced; pending > 0; pending-- {
+		select {
+		case e := <-delivery:
+			m, ok := e.(*kafka.Message)
+			if !ok {
+				pending++
+				continue
+			}
+			results[m.Opaque.(int)] = m.TopicPartition.Error
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	acked := 0
+	for acked < produced && results[acked] == nil {
+		log.Printf("Published %s outbox %d to %s, %v after creation",
+			events[acked].EventType, events[acked].ID, p.router.topic(events[acked]), publishLag(events[acked]))
+		acked++
+	}
+	if acked < produced {
+		return acked, fmt.Errorf("deliver outbox %d: %w", events[acked].ID, results[acked])
+	}
+	return acked, produceErr
+}
+
+func (p *publisher) message(outbox *Outbox, i int) *kafka.Message {
+	topic := p.router.topic(outbox)
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            []byte(outbox.AggregateID),
+		Value:          []byte(outbox.Payload),
+		Headers:        messageHeaders(outbox),
+		// Delivery reports for different partitions can arrive out of
+		// order; this says which event each one is for.
+		Opaque: i,
+	}
+}
+
+// messageHeaders returns the row's own headers, sorted by name, followed by
+// the outbox ID and event type. Headers that aren't a JSON object of
+// strings are logged and left off rather than holding the event back.
+func messageHeaders(outbox *Outbox) []kafka.Header {
+	var headers []kafka.Header
+	if outbox.Headers != nil {
+		var fields map[string]string
+		if err := json.Unmarshal([]byte(*outbox.Headers), &fields); err != nil {
+			log.Printf("ignoring headers of outbox %d: %v", outbox.ID, err)
+		}
+		for _, k := range slices.Sorted(maps.Keys(fields)) {
+			headers = append(headers, kafka.Header{Key: k, Value: []byte(fields[k])})
+		}
+	}
+	return append(headers,
+		kafka.Header{Key: outboxIDHeader, Value: []byte(strconv.FormatInt(outbox.ID, 10))},
+		kafka.Header{Key: eventTypeHeader, Value: []byte(outbox.EventType)},
+	)
+}
+
+// publishLag is how long the event waited in the outbox, for comparing how
+// quickly each mode picks rows up.
+func publishLag(outbox *Outbox) time.Duration {
+	created, err := time.Parse(time.RFC3339Nano, outbox.CreatedAt)
+	if err != nil {
+		return 0
+	}
+	return time.Since(created).Round(time.Millisecond)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// router picks the topic for each outbox event, the way Debezium's outbox
+// event router does: by aggregate type, with the routing table able to
+// single out event types or send aggregates elsewhere.
+type router struct {
+	// routes maps "AggregateType" or "AggregateType.EventType" to a topic.
+	routes map[string]string
+	// fallback is the topic for events without a route. {aggregate_type}
+	// and {event_type} are replaced with the event's.
+	fallback string
+}
+
+// parseRoutes parses a routing table of comma-separated
+// "AggregateType=topic" and "AggregateType.EventType=topic" entries.
+func parseRoutes(table, fallback string) (*router, error) {
+	r := &router{routes: make(map[string]string), fallback: fallback}
+	if table == "" {
+		return r, nil
+	}
+	for _, entry := range strings.Split(table, ",") {
+		key, topic, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || key == "" || topic == "" {
+			return nil, fmt.Errorf("route %q is not AggregateType[.EventType]=topic", entry)
+		}
+		if _, dup := r.routes[key]; dup {
+			return nil, fmt.Errorf("route for %s given twice", key)
+		}
+		r.routes[key] = topic
+	}
+	return r, nil
+}
+
+// topic returns where outbox goes: the route for its event type if there
+// is one, else the route for its aggregate type, else the fallback.
+func (r *router) topic(outbox *Outbox) string {
+	if topic, ok := r.routes[outbox.AggregateType+"."+outbox.EventType]; ok {
+		return topic
+	}
+	if topic, ok := r.routes[outbox.AggregateType]; ok {
+		return topic
+	}
+	return strings.NewReplacer(
+		"{aggregate_type}", outbox.AggregateType,
+		"{event_type}", outbox.EventType,
+	).Replace(r.fallback)
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestRouterTopic(t *testing.T) {
+	const fallback = "outbox.event.{aggregate_type}"
+	tests := []struct {
+		name          string
+		routes        string
+		fallback      string
+		aggregateType string
+		eventType     string
+		want          string
+	}{
+		{"no routes", "", fallback, "Order", "OrderCreated", "outbox.event.Order"},
+		{"aggregate route", "Order=orders", fallback, "Order", "OrderCreated", "orders"},
+		{"event route", "Order.OrderCancelled=order-cancellations", fallback, "Order", "OrderCancelled", "order-cancellations"},
+		{"event route wins over aggregate route", "Order=orders,Order.OrderCancelled=order-cancellations", fallback, "Order", "OrderCancelled", "order-cancellations"},
+		{"other events of a routed aggregate", "Order=orders,Order.OrderCancelled=order-cancellations", fallback, "Order", "OrderCreated", "orders"},
+		{"event route only, other event falls back", "Order.OrderCancelled=order-cancellations", fallback, "Order", "OrderCreated", "outbox.event.Order"},
+		{"unknown aggregate type", "Order=orders", fallback, "Invoice", "InvoiceIssued", "outbox.event.Invoice"},
+		{"event type of another aggregate", "Order.Created=orders", fallback, "Invoice", "Created", "outbox.event.Invoice"},
+		{"spaces around entries", " Order=orders , Invoice=invoices ", fallback, "Invoice", "InvoiceIssued", "invoices"},
+		{"fallback with both placeholders", "", "{aggregate_type}.{event_type}", "Order", "OrderShipped", "Order.OrderShipped"},
+		{"fallback without placeholders", "", "events", "Order", "OrderShipped", "events"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := parseRoutes(tc.routes, tc.fallback)
+			if err != nil {
+				t.Fatalf("parseRoutes(%q) failed: %v", tc.routes, err)
+			}
+			got := r.topic(&Outbox{AggregateType: tc.aggregateType, EventType: tc.eventType})
+			if got != tc.want {
+				t.Errorf("topic for %s.%s = %q, want %q", tc.aggregateType, tc.eventType, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRoutesRejectsBadEntries(t *testing.T) {
+	for _, table := range []string{
+		"Order",
+		"=orders",
+		"Order=",
+		"Order=orders,",
+		"Order=orders,Order=others",
+		"Order.OrderCreated=a,Order.OrderCreated=b",
+	} {
+		if _, err := parseRoutes(table, "outbox.event.{aggregate_type}"); err == nil {
+			t.Errorf("parseRoutes(%q) succeeded, want an error", table)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+-- Lets one outbox carry several kinds of event. The relay routes each to a
+-- topic by aggregate_type and event_type and copies headers, a JSON object
+-- of strings, onto the Kafka message.
+ALTER TABLE outbox
+    ADD COLUMN aggregate_type VARCHAR(255) NULL AFTER id,
+    ADD COLUMN event_type VARCHAR(255) NULL AFTER aggregate_id,
+    ADD COLUMN headers JSON NULL AFTER payload;
+
+-- Every event written before this migration was an order being created.
+UPDATE outbox SET aggregate_type = 'Order', event_type = 'OrderCreated';
+
+ALTER TABLE outbox
+    MODIFY aggregate_type VARCHAR(255) NOT NULL,
+    MODIFY event_type VARCHAR(255) NOT NULL;